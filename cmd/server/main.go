@@ -19,6 +19,15 @@ func main() {
 	}
 	configs.LoadLogger()
 	configs.LoadCacheConfig()
+	configs.LoadFetcherConfig()
+	configs.LoadAnalyzeConfig()
+	configs.LoadSchedulerConfig()
+	configs.LoadCrawlConfig()
+	configs.LoadRobotsConfig()
+	configs.LoadSitemapConfig()
+	configs.LoadJobsConfig()
+	configs.LoadSSEConfig()
+	configs.LoadBatchConfig()
 	api.Router(r)
 	err = r.Run(":" + configs.GetPort())
 	if err != nil {
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// exit codes, exitFailed is returned when a page could not be analyzed or violates a threshold
+const (
+	exitOk     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// command line analyzer for ci pipelines, analyzes urls, local html files and html read from stdin (-) without running the server
+// the fetcher, scheduler and analyze timeout are configured from the same env variables as the server
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// cli options, max broken below zero disables the broken links check
+type options struct {
+	format       string
+	root         string
+	baseUrl      string
+	offline      bool
+	allowPrivate bool
+	maxBroken    int
+	requireTitle bool
+	timeout      time.Duration
+	workers      int
+	checks       string
+}
+
+// a page to analyze, fetcher serves the page itself and checks its links
+type target struct {
+	url     string
+	fetcher fetcher.BodyFetcher
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// loaded before the flags since their defaults come from the env, a missing .env only leaves the env as it is
+	if err := configs.LoadEnv(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("webanalyzer", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: webanalyzer [flags] <url, html file or - for stdin>...")
+		flags.PrintDefaults()
+	}
+	opts := options{}
+	flags.StringVar(&opts.format, "format", formatTable, "output format: table, json or ndjson")
+	flags.StringVar(&opts.root, "root", ".", "site root of the local files, links starting with / are resolved from it")
+	flags.StringVar(&opts.baseUrl, "base-url", fetcher.RawPageUrl, "url the html read from stdin is published at, its relative links are resolved against it")
+	flags.BoolVar(&opts.offline, "offline", false, "do not fetch remote urls, remote links are reported as broken")
+	flags.BoolVar(&opts.allowPrivate, "allow-private", false, "allow requests to private and reserved addresses (localhost, staging networks), overrides FETCH_BLOCK_PRIVATE")
+	flags.IntVar(&opts.maxBroken, "max-broken", -1, "fail when a page has more broken links (-1 disables the check)")
+	flags.BoolVar(&opts.requireTitle, "require-title", false, "fail when a page has no title")
+	flags.DurationVar(&opts.timeout, "timeout", configs.GetAnalyzeConfig().Timeout, "deadline of a single page analysis")
+	flags.IntVar(&opts.workers, "workers", runtime.NumCPU(), "concurrent link checks per page")
+	flags.StringVar(&opts.checks, "checks", "", "comma separated checks to run, empty runs the default checks (available: "+strings.Join(analyzers.Checks(), ",")+")")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 || !validFormat(opts.format) {
+		flags.Usage()
+		return exitUsage
+	}
+	if opts.workers < 1 {
+		fmt.Fprintln(stderr, "workers must be at least 1")
+		return exitUsage
+	}
+	checks, err := analyzers.ParseChecks(opts.checks)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	files := &fetcher.FileFetcher{Root: opts.root}
+	if !opts.offline {
+		fetcherConfig := configs.GetFetcherConfig()
+		if opts.allowPrivate {
+			fetcherConfig.BlockPrivateTargets = false
+		}
+		files.Remote = fetcher.NewFetcher(fetcherConfig)
+	}
+	var robots *fetcher.RobotsCache
+	if robotsConfig := configs.GetRobotsConfig(); robotsConfig.CheckLinks {
+		robots = fetcher.NewRobotsCache(files, robotsConfig.UserAgent, robotsConfig.CacheTTL)
+	}
+	scheduler := analyzers.NewHostScheduler(configs.GetSchedulerConfig())
+
+	// every target is resolved before the first analysis, so an invalid one does not drop the reports of the others
+	var targets []target
+	for _, arg := range flags.Args() {
+		t := target{fetcher: files}
+		var err error
+		if arg == "-" {
+			t.url = opts.baseUrl
+			t.fetcher, err = stdinFetcher(stdin, t.url, files)
+		} else {
+			t.url, err = targetUrl(arg, opts.root)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		targets = append(targets, t)
+	}
+
+	printer := newPrinter(opts.format, stdout)
+	code := exitOk
+	for _, t := range targets {
+		a := &analyzers.BodyAnalyzer{
+			Fetcher:   t.fetcher,
+			Scheduler: scheduler,
+			Robots:    robots,
+			Stream:    make(chan string, 20),
+			Output:    models.Output{},
+			Workers:   opts.workers,
+			Checks:    checks,
+		}
+		r := analyze(ctx, a, t.url, opts.timeout)
+		r.Violations = opts.check(r)
+		if r.Error != nil || len(r.Violations) > 0 {
+			code = exitFailed
+		}
+		printer.add(r)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	printer.flush()
+	return code
+}
+
+// urls are analyzed as they are, anything else is a local file or directory under root
+func targetUrl(target, root string) (string, error) {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "file://") {
+		return target, nil
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", err
+	}
+	// a directory is analyzed through its index.html so relative links resolve from the directory
+	if info.IsDir() {
+		target = filepath.Join(target, "index.html")
+	}
+	return fetcher.FileUrl(root, target)
+}
+
+// fetcher of the page read from stdin, its links are checked by files
+func stdinFetcher(stdin io.Reader, url string, files *fetcher.FileFetcher) (fetcher.BodyFetcher, error) {
+	html, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, err
+	}
+	return &fetcher.ReaderFetcher{Url: url, Html: html, Remote: files}, nil
+}
+
+// runs the analysis and keeps the final result event of its stream
+func analyze(ctx context.Context, a *analyzers.BodyAnalyzer, url string, timeout time.Duration) report {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	last := make(chan string)
+	go func() {
+		var msg string
+		for msg = range a.Stream {
+		}
+		last <- msg
+	}()
+
+	r := report{Url: url}
+	r.Error = a.Analyze(ctx, url)
+	close(a.Stream)
+	msg := <-last
+	if r.Error != nil {
+		return r
+	}
+
+	event := struct {
+		Op   string
+		Data *models.AnalysisResult
+	}{}
+	if err := json.Unmarshal([]byte(msg), &event); err != nil || event.Op != models.EventResult {
+		r.Error = &models.ErrorOut{Code: models.ErrCodeInternal, Error: "analysis ended without a result"}
+		return r
+	}
+	r.Result = event.Data
+	return r
+}
+
+// thresholds violated by the analyzed page
+func (o options) check(r report) []string {
+	if r.Result == nil {
+		return nil
+	}
+	var violations []string
+	if o.maxBroken >= 0 && r.Result.Summary.InactiveLinks > o.maxBroken {
+		violations = append(violations, fmt.Sprintf("%d broken links (max %d)", r.Result.Summary.InactiveLinks, o.maxBroken))
+	}
+	if o.requireTitle && r.Result.Title == "" {
+		violations = append(violations, "missing title")
+	}
+	return violations
+}
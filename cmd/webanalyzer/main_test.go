@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writes a small static site and returns its root
+func testSite(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":      `<html><head><title>Home</title></head><body><h1>Home</h1><a href="/about.html">About</a></body></html>`,
+		"about.html":      `<html><head><title>About</title></head><body><a href="/">Home</a></body></html>`,
+		"broken.html":     `<html><head><title>Broken</title></head><body><a href="/missing.html">Missing</a><a href="/gone.html">Gone</a></body></html>`,
+		"untitled.html":   `<html><body><a href="/index.html">Home</a></body></html>`,
+		"docs/index.html": `<html><head><title>Docs</title></head><body><a href="../about.html">About</a></body></html>`,
+	}
+	for name, html := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(html), 0o644))
+	}
+	return root
+}
+
+func TestRun(t *testing.T) {
+	root := testSite(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Remote</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	file := func(name string) string { return filepath.Join(root, name) }
+	testCases := []struct {
+		name         string
+		args         []string
+		stdin        string
+		expectCode   int
+		expectTitles []string
+		expectStderr string
+	}{
+		{name: "no target", args: []string{}, expectCode: exitUsage, expectStderr: "usage:"},
+		{name: "unknown flag", args: []string{"-nope", file("index.html")}, expectCode: exitUsage},
+		{name: "unknown format", args: []string{"-format", "xml", file("index.html")}, expectCode: exitUsage, expectStderr: "usage:"},
+		{name: "no workers", args: []string{"-workers", "0", file("index.html")}, expectCode: exitUsage, expectStderr: "workers must be at least 1"},
+		{name: "negative workers", args: []string{"-workers", "-1", file("index.html")}, expectCode: exitUsage, expectStderr: "workers must be at least 1"},
+		{name: "unknown check", args: []string{"-checks", "nope", file("index.html")}, expectCode: exitUsage, expectStderr: "nope"},
+		{name: "missing file", args: []string{file("nope.html")}, expectCode: exitUsage, expectStderr: "nope.html"},
+		{name: "file outside the root", args: []string{"-root", file("docs"), file("index.html")}, expectCode: exitUsage, expectStderr: "is not under"},
+		{name: "invalid target after a valid one", args: []string{"-root", root, "-format", "json", file("index.html"), file("nope.html")}, expectCode: exitUsage},
+		{
+			name:         "local files",
+			args:         []string{"-root", root, "-offline", "-format", "json", file("index.html"), file("docs")},
+			expectCode:   exitOk,
+			expectTitles: []string{"Home", "Docs"},
+		},
+		{
+			name:         "stdin",
+			args:         []string{"-root", root, "-offline", "-format", "json", "-"},
+			stdin:        `<html><head><title>Stdin</title></head><body><a href="/about.html">About</a></body></html>`,
+			expectCode:   exitOk,
+			expectTitles: []string{"Stdin"},
+		},
+		{
+			name:         "broken links within the threshold",
+			args:         []string{"-root", root, "-offline", "-format", "json", "-max-broken", "2", file("broken.html")},
+			expectCode:   exitOk,
+			expectTitles: []string{"Broken"},
+		},
+		{
+			name:         "too many broken links",
+			args:         []string{"-root", root, "-offline", "-format", "json", "-max-broken", "1", file("broken.html")},
+			expectCode:   exitFailed,
+			expectTitles: []string{"Broken"},
+		},
+		{
+			name:         "missing title",
+			args:         []string{"-root", root, "-offline", "-format", "json", "-require-title", file("index.html"), file("untitled.html")},
+			expectCode:   exitFailed,
+			expectTitles: []string{"Home", ""},
+		},
+		{
+			name:         "private target is refused",
+			args:         []string{"-format", "json", server.URL},
+			expectCode:   exitFailed,
+			expectTitles: []string{""},
+		},
+		{
+			name:         "private target is allowed",
+			args:         []string{"-format", "json", "-allow-private", server.URL},
+			expectCode:   exitOk,
+			expectTitles: []string{"Remote"},
+		},
+		{
+			name:         "remote target offline",
+			args:         []string{"-format", "json", "-offline", server.URL},
+			expectCode:   exitFailed,
+			expectTitles: []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			assert.Equal(t, tc.expectCode, code, stderr.String())
+			assert.Contains(t, stderr.String(), tc.expectStderr)
+			if tc.expectCode == exitUsage {
+				assert.Empty(t, stdout.String())
+				return
+			}
+
+			var reports []report
+			assert.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+			var titles []string
+			for _, r := range reports {
+				title := ""
+				if r.Result != nil {
+					title = r.Result.Title
+				}
+				titles = append(titles, title)
+			}
+			assert.Equal(t, tc.expectTitles, titles)
+		})
+	}
+}
+
+func TestRunFormats(t *testing.T) {
+	root := testSite(t)
+	args := []string{"-root", root, "-offline", "-max-broken", "0", filepath.Join(root, "index.html"), filepath.Join(root, "broken.html")}
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-format", "table"}, args...), nil, &stdout, &stderr)
+	assert.Equal(t, exitFailed, code)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "URL"))
+	assert.Contains(t, lines[1], "ok")
+	assert.Contains(t, lines[2], "fail: 2 broken links (max 0)")
+
+	stdout.Reset()
+	code = run(context.Background(), append([]string{"-format", "ndjson"}, args...), nil, &stdout, &stderr)
+	assert.Equal(t, exitFailed, code)
+	lines = strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		var r report
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+		assert.NotNil(t, r.Result)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// result of a single url, error is set when the page could not be analyzed
+// violations lists the thresholds the page does not meet
+type report struct {
+	Url        string
+	Result     *models.AnalysisResult
+	Error      *models.ErrorOut
+	Violations []string
+}
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatNDJSON
+}
+
+// writes the reports in the chosen format
+// ndjson lines are written as soon as a page is done, table and json once all the pages are done
+type printer struct {
+	format  string
+	out     io.Writer
+	reports []report
+}
+
+func newPrinter(format string, out io.Writer) *printer {
+	return &printer{format: format, out: out, reports: []report{}}
+}
+
+func (p *printer) add(r report) {
+	if p.format == formatNDJSON {
+		line, _ := json.Marshal(r)
+		fmt.Fprintln(p.out, string(line))
+		return
+	}
+	p.reports = append(p.reports, r)
+}
+
+func (p *printer) flush() {
+	switch p.format {
+	case formatJSON:
+		data, _ := json.MarshalIndent(p.reports, "", "  ")
+		fmt.Fprintln(p.out, string(data))
+	case formatTable:
+		p.table()
+	}
+}
+
+func (p *printer) table() {
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "URL\tTITLE\tHEADERS\tINTERNAL\tEXTERNAL\tBROKEN\tLOGIN\tTIME\tRESULT")
+	for _, r := range p.reports {
+		if r.Result == nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\t-\terror: %s\n", r.Url, r.Error.Error)
+			continue
+		}
+		summary := r.Result.Summary
+		title := r.Result.Title
+		if title == "" {
+			title = "-"
+		}
+		result := "ok"
+		if len(r.Violations) > 0 {
+			result = "fail: " + strings.Join(r.Violations, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%t\t%dms\t%s\n", r.Url, title, summary.Headers,
+			summary.InternalLinks, summary.ExternalLinks, summary.InactiveLinks, summary.IsLogin, summary.DurationMs, result)
+	}
+	w.Flush()
+}
@@ -0,0 +1,269 @@
+package analyzers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+func init() {
+	Register("a11y", func(a *BodyAnalyzer) TokenAnalyzer {
+		return &a11yCheck{a: a, ids: map[string]bool{}, labelFor: map[string]bool{}}
+	}, true)
+}
+
+// a static accessibility rule, wcag is the success criterion it checks
+type a11yRule struct {
+	id       string
+	wcag     string
+	severity string
+	message  string
+}
+
+var (
+	ruleImgAlt      = a11yRule{"img-alt", "1.1.1", models.A11ySeverityError, "image without an alt attribute"}
+	ruleLabel       = a11yRule{"label", "1.3.1", models.A11ySeverityError, "form field without a label"}
+	ruleHtmlLang    = a11yRule{"html-lang", "3.1.1", models.A11ySeverityError, "html element without a lang attribute"}
+	ruleLinkName    = a11yRule{"link-name", "2.4.4", models.A11ySeverityError, "link without text"}
+	ruleLinkText    = a11yRule{"link-text", "2.4.4", models.A11ySeverityWarning, "link text does not describe its target"}
+	ruleButtonName  = a11yRule{"button-name", "4.1.2", models.A11ySeverityError, "button without an accessible name"}
+	ruleDuplicateId = a11yRule{"duplicate-id", "4.1.1", models.A11ySeverityError, "id used by more than one element"}
+	ruleTabindex    = a11yRule{"tabindex", "2.4.3", models.A11ySeverityWarning, "positive tabindex changes the focus order"}
+)
+
+// link texts that say nothing about the target of the link
+var genericLinkTexts = map[string]bool{
+	"click": true, "click here": true, "here": true, "more": true, "read more": true,
+	"learn more": true, "link": true, "this link": true, "details": true,
+}
+
+// input types that do not need a label
+var unlabelledInputs = map[string]bool{"hidden": true, "submit": true, "reset": true, "button": true, "image": true}
+
+// max length of the snippet of a finding
+const maxSnippetLength = 120
+
+// collects the text of an open link or button to tell if it has an accessible name
+// named is set when an aria-label, aria-labelledby or title gives the name
+type nameCollector struct {
+	open    bool
+	named   bool
+	text    []string
+	snippet string
+	line    int
+}
+
+// a form field without a label around it, labelled later when a label points to its id
+type pendingField struct {
+	id      string
+	finding models.A11yFinding
+}
+
+// static wcag checks on the tokens of the page
+// labels can come after their field, so fields are only reported once the whole page is read
+type a11yCheck struct {
+	a        *BodyAnalyzer
+	findings []models.A11yFinding
+	ids      map[string]bool
+	labelFor map[string]bool
+	fields   []pendingField
+	inLabel  int
+	seenHtml bool
+	link     nameCollector
+	button   nameCollector
+}
+
+func (c *a11yCheck) Token(tokenType html.TokenType, token html.Token) error {
+	switch tokenType {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		c.startTag(token, tokenType == html.SelfClosingTagToken)
+	case html.EndTagToken:
+		c.endTag(token.Data)
+	case html.TextToken:
+		c.addText(token.Data)
+	}
+	return nil
+}
+
+func (c *a11yCheck) startTag(token html.Token, selfClosing bool) {
+	if id := strings.TrimSpace(attr(token, "id")); id != "" {
+		if c.ids[id] {
+			c.report(ruleDuplicateId, token, fmt.Sprintf("%s: %s", ruleDuplicateId.message, id))
+		}
+		c.ids[id] = true
+	}
+	if tabindex, err := strconv.Atoi(strings.TrimSpace(attr(token, "tabindex"))); err == nil && tabindex > 0 {
+		c.report(ruleTabindex, token, "")
+	}
+
+	switch token.Data {
+	case "html":
+		c.seenHtml = true
+		if strings.TrimSpace(attr(token, "lang")) == "" {
+			c.report(ruleHtmlLang, token, "")
+		}
+	case "img":
+		if !hasAttr(token, "alt") {
+			c.report(ruleImgAlt, token, "")
+		}
+		// the alt text of an image is the name of the link or button around it
+		c.addText(attr(token, "alt"))
+	case "a":
+		if hasAttr(token, "href") && !selfClosing {
+			c.link = c.collect(token)
+		}
+	case "button":
+		if !selfClosing {
+			c.button = c.collect(token)
+		}
+	case "label":
+		if id := strings.TrimSpace(attr(token, "for")); id != "" {
+			c.labelFor[id] = true
+		}
+		if !selfClosing {
+			c.inLabel++
+		}
+	case "input":
+		c.input(token)
+	case "select", "textarea":
+		c.field(token)
+	}
+}
+
+func (c *a11yCheck) input(token html.Token) {
+	inputType := strings.ToLower(strings.TrimSpace(attr(token, "type")))
+	switch {
+	case inputType == "image" && strings.TrimSpace(attr(token, "alt")) == "":
+		c.report(ruleImgAlt, token, "")
+	case inputType == "button" && strings.TrimSpace(attr(token, "value")) == "" && !hasAriaName(token):
+		c.report(ruleButtonName, token, "")
+	case !unlabelledInputs[inputType]:
+		c.field(token)
+	}
+}
+
+// a form field is labelled by a label around it, an aria name or a label pointing to its id
+func (c *a11yCheck) field(token html.Token) {
+	if c.inLabel > 0 || hasAriaName(token) {
+		return
+	}
+	c.fields = append(c.fields, pendingField{id: strings.TrimSpace(attr(token, "id")), finding: c.finding(ruleLabel, token, "")})
+}
+
+func (c *a11yCheck) endTag(tag string) {
+	switch tag {
+	case "a":
+		if c.link.open {
+			c.checkName(&c.link, ruleLinkName, true)
+		}
+	case "button":
+		if c.button.open {
+			c.checkName(&c.button, ruleButtonName, false)
+		}
+	case "label":
+		if c.inLabel > 0 {
+			c.inLabel--
+		}
+	}
+}
+
+func (c *a11yCheck) collect(token html.Token) nameCollector {
+	return nameCollector{open: true, named: hasAriaName(token), snippet: snippet(token), line: c.a.Line()}
+}
+
+func (c *a11yCheck) addText(text string) {
+	if c.link.open {
+		c.link.text = append(c.link.text, text)
+	}
+	if c.button.open {
+		c.button.text = append(c.button.text, text)
+	}
+}
+
+// reports a link or button without a name, links with a generic text are reported as a warning
+func (c *a11yCheck) checkName(collector *nameCollector, rule a11yRule, checkText bool) {
+	text := strings.Join(strings.Fields(strings.Join(collector.text, " ")), " ")
+	if !collector.named {
+		if text == "" {
+			c.add(rule, collector.snippet, collector.line, "")
+		} else if checkText && genericLinkTexts[strings.Trim(strings.ToLower(text), ".!:>» ")] {
+			c.add(ruleLinkText, collector.snippet, collector.line, fmt.Sprintf("%s: %q", ruleLinkText.message, text))
+		}
+	}
+	*collector = nameCollector{}
+}
+
+func (c *a11yCheck) Finalize() error {
+	for _, field := range c.fields {
+		if field.id == "" || !c.labelFor[field.id] {
+			c.findings = append(c.findings, field.finding)
+		}
+	}
+	if !c.seenHtml {
+		c.add(ruleHtmlLang, "", 0, "")
+	}
+	sort.SliceStable(c.findings, func(i, j int) bool { return c.findings[i].Line < c.findings[j].Line })
+	return nil
+}
+
+func (c *a11yCheck) Section() interface{} {
+	section := models.A11ySection{Findings: []models.A11yFinding{}}
+	for _, finding := range c.findings {
+		section.Findings = append(section.Findings, finding)
+		if finding.Severity == models.A11ySeverityError {
+			section.Errors++
+		} else {
+			section.Warnings++
+		}
+	}
+	return section
+}
+
+func (c *a11yCheck) report(rule a11yRule, token html.Token, message string) {
+	c.findings = append(c.findings, c.finding(rule, token, message))
+}
+
+func (c *a11yCheck) add(rule a11yRule, snippet string, line int, message string) {
+	c.findings = append(c.findings, newFinding(rule, snippet, line, message))
+}
+
+// finding at the current token
+func (c *a11yCheck) finding(rule a11yRule, token html.Token, message string) models.A11yFinding {
+	return newFinding(rule, snippet(token), c.a.Line(), message)
+}
+
+// an empty message is the message of the rule
+func newFinding(rule a11yRule, snippet string, line int, message string) models.A11yFinding {
+	if message == "" {
+		message = rule.message
+	}
+	return models.A11yFinding{Rule: rule.id, Severity: rule.severity, Wcag: rule.wcag, Message: message, Snippet: snippet, Line: line}
+}
+
+func hasAttr(token html.Token, key string) bool {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// tells if the element is named by aria-label, aria-labelledby or title
+func hasAriaName(token html.Token) bool {
+	return strings.TrimSpace(firstAttr(token, "aria-label", "aria-labelledby", "title")) != ""
+}
+
+// start tag of the element, cut at maxSnippetLength characters
+func snippet(token html.Token) string {
+	token.Type = html.StartTagToken
+	s := []rune(token.String())
+	if len(s) > maxSnippetLength {
+		return string(s[:maxSnippetLength]) + "..."
+	}
+	return string(s)
+}
@@ -0,0 +1,87 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_A11y(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<body>
+	<img src="logo.png">
+	<img src="spacer.png" alt="">
+	<a href="/a"><img src="home.png" alt="Home"></a>
+	<a href="/b"></a>
+	<a href="/c">Click here</a>
+	<a href="/d" aria-label="Pricing">here</a>
+	<button><span>Send</span></button>
+	<button type="submit"></button>
+	<input type="button">
+	<form>
+		<label>Name <input type="text" id="name"></label>
+		<label for="email">Email</label><input type="email" id="email">
+		<input type="password" id="password">
+		<input type="search" aria-label="Search">
+		<select id="country"></select><label for="country">Country</label>
+		<textarea></textarea>
+		<input type="hidden" name="token">
+	</form>
+	<div id="name" tabindex="2">x</div>
+</body>
+</html>`
+	a := BodyAnalyzer{
+		Fetcher: &fetcher.MockFetcher{ResponseBody: page},
+		Stream:  make(chan string, 50),
+		Workers: 1,
+		Checks:  []string{"a11y"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	a11y := a.Output.Sections["a11y"].(models.A11ySection)
+	type found struct {
+		Rule string
+		Line int
+	}
+	var findings []found
+	for _, finding := range a11y.Findings {
+		findings = append(findings, found{finding.Rule, finding.Line})
+	}
+	assert.Equal(t, []found{
+		{"html-lang", 2},
+		{"img-alt", 4},
+		{"link-name", 7},
+		{"link-text", 8},
+		{"button-name", 11},
+		{"button-name", 12},
+		{"label", 16},
+		{"label", 19},
+		{"duplicate-id", 22},
+		{"tabindex", 22},
+	}, findings)
+	assert.Equal(t, 8, a11y.Errors)
+	assert.Equal(t, 2, a11y.Warnings)
+
+	imgAlt := a11y.Findings[1]
+	assert.Equal(t, models.A11ySeverityError, imgAlt.Severity)
+	assert.Equal(t, "1.1.1", imgAlt.Wcag)
+	assert.Equal(t, `<img src="logo.png">`, imgAlt.Snippet)
+	assert.Equal(t, `link text does not describe its target: "Click here"`, a11y.Findings[3].Message)
+}
+
+func Test_A11y_NoHtmlElement(t *testing.T) {
+	a := BodyAnalyzer{
+		Fetcher: &fetcher.MockFetcher{ResponseBody: `<p lang="en">fragment</p>`},
+		Stream:  make(chan string, 50),
+		Workers: 1,
+		Checks:  []string{"a11y"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	a11y := a.Output.Sections["a11y"].(models.A11ySection)
+	assert.Equal(t, []models.A11yFinding{{Rule: "html-lang", Severity: models.A11ySeverityError, Wcag: "3.1.1", Message: "html element without a lang attribute"}}, a11y.Findings)
+}
@@ -2,10 +2,15 @@ package analyzers
 
 import (
 	//"encoding/base32"
+	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/RidmaTP/web-analyzer/internal/fetcher"
 	"github.com/RidmaTP/web-analyzer/internal/models"
@@ -14,89 +19,219 @@ import (
 )
 
 // body analyzer configuration fields
-// stream is the channel used to stream the output to the frontend as a server sent event
+// stream is the channel used to stream the output to the frontend as server sent events (a snapshot, delta events and the final result)
 // output is the data struct used to define output structure
-// muActiveLinks and muInactiveLinks are used to avoid the race conditions for the necessary link slices
+// muActiveLinks, muInactiveLinks, muBlockedLinks and muLinkResults are used to avoid the race conditions for the necessary link slices
 // wg is a waitgroup used to synchronize workerpool
 // workers define the size of the worker pool
+// scheduler applies the per host politeness limits to the workers, nil means no limits
+// robots makes the workers skip links disallowed by robots.txt and wait its crawl-delay between the checks of a host, nil means robots.txt is not checked
+// ctx is the context of the running analysis, stream sends give up once it is done
+// checks are the names of the registered checks to run (see Register), nil runs the default checks
+// url and linkJobQueue are the page url and the job queue of the running analysis, used by the links check
+// linkChecks holds the check of every resolved url, so a url is only requested once per analysis, muLinkChecks guards it
+// line is the line of the token being analyzed
 type BodyAnalyzer struct {
 	Fetcher         fetcher.BodyFetcher
+	Scheduler       *HostScheduler
+	Robots          *fetcher.RobotsCache
 	Stream          chan string
 	Output          models.Output
 	muActiveLinks   sync.Mutex
 	muInactiveLinks sync.Mutex
+	muBlockedLinks  sync.Mutex
+	muLinkResults   sync.Mutex
+	muLinkChecks    sync.Mutex
+	linkChecks      map[string]*linkCheck
 	wg              *sync.WaitGroup
+	ctx             context.Context
+	url             string
+	linkJobQueue    chan models.LinkJob
+	anchors         []string
+	line            int
 	Workers         int
+	Checks          []string
 }
 
+// status used when the client went away before the analysis finished
+const statusClientClosedRequest = 499
+
 // main function of the analyzation process
 // gets the reader using fetchbody func
 // then it tokenizes the content and goes through the tokens
-// all analytics are collected when going through all the tokens once
+// all analytics are collected when going through all the tokens once, every enabled check gets every token
+// the checks are finalized once the link checks are done
 // during the scraping process, once a result is found they will be pushed to the frontend in realtime using http1.1 SSE
 // job queue wth a worker pool is used to improve the performance of finding active/inactive links
-func (a *BodyAnalyzer) Analyze(url string) *models.ErrorOut {
-	var inTitle bool
-	a.muActiveLinks, a.muInactiveLinks = sync.Mutex{}, sync.Mutex{}
+// the analysis stops as soon as ctx is done (client went away, job cancelled or deadline reached)
+// the in flight requests are aborted and the worker pool is stopped before returning
+func (a *BodyAnalyzer) Analyze(ctx context.Context, url string) *models.ErrorOut {
+	a.muActiveLinks, a.muInactiveLinks, a.muBlockedLinks, a.muLinkResults = sync.Mutex{}, sync.Mutex{}, sync.Mutex{}, sync.Mutex{}
+	a.muLinkChecks, a.linkChecks = sync.Mutex{}, nil
 	a.wg = &sync.WaitGroup{}
-	linkJobQueue := make(chan string, a.Workers)
-	loginFlags := models.LoginFlags{}
+	a.ctx = ctx
+	a.url = url
+	start := time.Now()
+	linkJobQueue := make(chan models.LinkJob, a.Workers)
+	a.linkJobQueue = linkJobQueue
+	checks := a.newChecks()
+	line := 1
 
-	ioReader, err := a.Fetcher.FetchBody(url)
+	ioReader, err := a.Fetcher.FetchBody(ctx, url)
 	if err != nil {
-		return &models.ErrorOut{StatusCode: http.StatusBadGateway, Error: err.Error()}
+		if ctx.Err() != nil {
+			return contextErrOut(ctx.Err())
+		}
+		return fetchErrOut(err)
 	}
 	defer ioReader.Close()
 	tokenizer := html.NewTokenizer(ioReader)
 
+	if err := a.emit(models.EventSnapshot, a.Output); err != nil {
+		return a.errOut(err)
+	}
+
 	for i := 0; i < a.Workers; i++ {
 		a.wg.Add(1)
-		go func(a *BodyAnalyzer, linkJobQueue *chan string, baseUrl string) {
+		go func(a *BodyAnalyzer, linkJobQueue *chan models.LinkJob, baseUrl string) {
 			defer a.wg.Done()
-			a.ActiveCheckWorker(baseUrl, linkJobQueue)
+			a.ActiveCheckWorker(ctx, baseUrl, linkJobQueue)
 
 		}(a, &linkJobQueue, url)
 	}
+	stopWorkers := sync.OnceFunc(func() {
+		close(linkJobQueue)
+		a.wg.Wait()
+	})
+	defer stopWorkers()
 
 	for {
+		if ctx.Err() != nil {
+			return contextErrOut(ctx.Err())
+		}
 		tokenType := tokenizer.Next()
 		if tokenType == html.ErrorToken {
 			err := tokenizer.Err()
 			if err == io.EOF {
 				break
 			}
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
+			// the body is read while tokenizing, a failed read is a fetch error of the page
+			if ctx.Err() != nil {
+				return contextErrOut(ctx.Err())
+			}
+			return fetchErrOut(err)
 		}
+		// raw must be read before the token, it is only valid until the token is parsed
+		a.line = line
+		line += bytes.Count(tokenizer.Raw(), []byte("\n"))
 		token := tokenizer.Token()
 
-		isInTitle, err := a.FindTitle(tokenType, token, inTitle)
-		if err != nil {
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
+		for _, check := range checks {
+			if err := check.Token(tokenType, token); err != nil {
+				return a.errOut(err)
+			}
 		}
-		inTitle = isInTitle
+	}
+	stopWorkers()
+	if ctx.Err() != nil {
+		return contextErrOut(ctx.Err())
+	}
+	if err := a.finalizeChecks(checks); err != nil {
+		return a.errOut(err)
+	}
 
-		err = a.FindHTMLVersion(tokenType, token)
-		if err != nil {
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
-		}
+	// the workers are done, so the output is complete and no longer changing
+	if err := a.emit(models.EventResult, models.AnalysisResult{Output: a.Output, Summary: a.summary(time.Since(start))}); err != nil {
+		return a.errOut(err)
+	}
+	return nil
+}
 
-		err = a.FindHeaderCount(tokenType, token)
-		if err != nil {
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
-		}
+// summary stats of the output, must only be called once the workers are done
+func (a *BodyAnalyzer) summary(duration time.Duration) models.AnalysisSummary {
+	summary := models.AnalysisSummary{
+		InternalLinks: a.Output.InternalLinks.Count,
+		ExternalLinks: a.Output.ExternalLinks.Count,
+		ActiveLinks:   a.Output.ActiveLinks.Count,
+		InactiveLinks: a.Output.InactiveLinks.Count,
+		BlockedLinks:  a.Output.BlockedLinks.Count,
+		IsLogin:       a.Output.IsLogin,
+		DurationMs:    duration.Milliseconds(),
+	}
+	for _, count := range a.Output.Headers {
+		summary.Headers += count
+	}
+	for _, result := range a.Output.LinkResults {
+		summary.Retries += result.Retries
+	}
+	return summary
+}
 
-		err = a.FindLinks(tokenType, token, url, &linkJobQueue)
-		if err != nil {
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
-		}
-		err = a.FindIfLogin(tokenType, token, &loginFlags)
-		if err != nil {
-			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Error: err.Error()}
-		}
+// maps an error of the token loop into an ErrorOut
+// a done context takes precedence since it is usually the reason the body could not be read
+func (a *BodyAnalyzer) errOut(err error) *models.ErrorOut {
+	if a.ctx != nil && a.ctx.Err() != nil {
+		return contextErrOut(a.ctx.Err())
+	}
+	return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Code: models.ErrCodeInternal, Error: err.Error()}
+}
+
+func contextErrOut(err error) *models.ErrorOut {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &models.ErrorOut{StatusCode: http.StatusGatewayTimeout, Code: models.ErrCodeAnalysisTimeout, Error: "analysis timed out", Retryable: true}
+	}
+	return &models.ErrorOut{StatusCode: statusClientClosedRequest, Code: models.ErrCodeCancelled, Error: "analysis cancelled", Retryable: true}
+}
+
+// maps the error of fetching the analyzed page into an ErrorOut with a machine readable code
+// retryable is set for the failures that are likely transient
+func fetchErrOut(err error) *models.ErrorOut {
+	errObj := &models.ErrorOut{StatusCode: http.StatusBadGateway, Code: models.ErrCodeFetchFailed, Error: err.Error()}
+	switch fetcher.ClassifyError(err) {
+	case fetcher.ErrClassTimeout:
+		errObj.StatusCode, errObj.Code, errObj.Retryable = http.StatusGatewayTimeout, models.ErrCodeFetchTimeout, true
+	case fetcher.ErrClassDNS:
+		errObj.Code = models.ErrCodeDNSFailure
+	case fetcher.ErrClassConnRefused, fetcher.ErrClassConnReset:
+		errObj.Code, errObj.Retryable = models.ErrCodeConnectionFailed, true
+	case fetcher.ErrClassTLS:
+		errObj.Code = models.ErrCodeTLSFailure
+	case fetcher.ErrClassRedirects:
+		errObj.Code = models.ErrCodeTooManyRedirects
+	case fetcher.ErrClassBlocked:
+		errObj.StatusCode, errObj.Code = http.StatusForbidden, models.ErrCodeBlockedTarget
+	case fetcher.ErrClassInvalidUrl:
+		errObj.StatusCode, errObj.Code = http.StatusBadRequest, models.ErrCodeInvalidUrl
+	case fetcher.ErrClassHttpStatus:
+		var statusErr *fetcher.StatusError
+		errors.As(err, &statusErr)
+		errObj.Code, errObj.Retryable = models.ErrCodeUpstreamStatus, statusErr.Temporary()
+	}
+	return errObj
+}
+
+// done channel of the running analysis, nil (blocks forever) when no analysis is running
+func (a *BodyAnalyzer) done() <-chan struct{} {
+	if a.ctx == nil {
+		return nil
 	}
-	close(linkJobQueue)
-	a.wg.Wait()
+	return a.ctx.Done()
+}
 
+// pushes an event into the stream, gives up when the analysis is cancelled so a gone client can not block it
+// only the changed data is sent, the full output is sent as the first and the last event
+func (a *BodyAnalyzer) emit(op string, data interface{}) error {
+	if a.Stream == nil {
+		return nil
+	}
+	jsonStr, err := utils.JsonToText(models.StreamEvent{Op: op, Data: data})
+	if err != nil {
+		return err
+	}
+	select {
+	case a.Stream <- *jsonStr:
+	case <-a.done():
+	}
 	return nil
 }
 
@@ -117,11 +252,9 @@ func (a *BodyAnalyzer) FindTitle(tokenType html.TokenType, token html.Token, inT
 			trimmed := strings.TrimSpace(string(token.Data))
 			if trimmed != "" {
 				a.Output.Title = trimmed
-				jsonStr, err := utils.JsonToText(a.Output)
-				if err != nil {
+				if err := a.emit(models.EventTitle, trimmed); err != nil {
 					return inTitle, err
 				}
-				a.Stream <- *jsonStr
 				return inTitle, nil
 			}
 		}
@@ -151,11 +284,9 @@ func (a *BodyAnalyzer) FindHTMLVersion(tokenType html.TokenType, token html.Toke
 	}
 	if version != "" {
 		a.Output.Version = version
-		jsonStr, err := utils.JsonToText(a.Output)
-		if err != nil {
+		if err := a.emit(models.EventVersion, version); err != nil {
 			return err
 		}
-		a.Stream <- *jsonStr
 	}
 	return nil
 }
@@ -169,41 +300,59 @@ func (a *BodyAnalyzer) FindHeaderCount(tokenType html.TokenType, token html.Toke
 		header := token.Data
 		if header == "h1" || header == "h2" || header == "h3" || header == "h4" || header == "h5" || header == "h6" {
 			a.Output.Headers[header]++
-			jsonStr, err := utils.JsonToText(a.Output)
-			if err != nil {
+			if err := a.emit(models.EventHeader, models.HeaderCount{Tag: header, Count: a.Output.Headers[header]}); err != nil {
 				return err
 			}
-			a.Stream <- *jsonStr
 		}
 	}
 	return nil
 }
 
+// tells if the fetcher can check the link once resolved against the page url
+// file links (the relative links of raw html without a base url) only can when the fetcher serves local files
+func (a *BodyAnalyzer) isCheckable(link, baseUrl string) bool {
+	if !utils.IsCheckableLink(link, baseUrl) {
+		return false
+	}
+	u, err := url.Parse(utils.AddInternalHost(strings.TrimSpace(link), baseUrl))
+	return err == nil && (!strings.EqualFold(u.Scheme, "file") || fetcher.ServesFiles(a.Fetcher))
+}
+
 // used to find the External,Internal links
 // acts as the producer of the linkJobQueue
 // when a link is found it checks if its internal/external and then pushes it to the job queue for a worker to check if its available
-func (a *BodyAnalyzer) FindLinks(tokenType html.TokenType, token html.Token, baseUrl string, linkJobQueue *chan string) error {
+// links the fetcher can not request (mailto:, tel:, javascript:) are counted but not checked
+func (a *BodyAnalyzer) FindLinks(tokenType html.TokenType, token html.Token, baseUrl string, linkJobQueue *chan models.LinkJob) error {
 	if tokenType == html.StartTagToken || tokenType == html.SelfClosingTagToken {
 		tokenData := token.Data
 		if tokenData == "a" || tokenData == "link" {
 			for _, attr := range token.Attr {
 				if attr.Key == "href" {
-					if utils.IsExternalLink(attr.Val, baseUrl) {
-						a.Output.ExternalLinks.Count++
-						a.Output.ExternalLinks.Links = append(a.Output.ExternalLinks.Links, attr.Val)
-					} else {
+					internal := !utils.IsExternalLink(attr.Val, baseUrl)
+					if internal {
 						a.Output.InternalLinks.Count++
 						a.Output.InternalLinks.Links = append(a.Output.InternalLinks.Links, attr.Val)
+						// only the a elements link to pages, link elements are stylesheets, icons and feeds
+						if tokenData == "a" {
+							a.anchors = append(a.anchors, attr.Val)
+						}
+					} else {
+						a.Output.ExternalLinks.Count++
+						a.Output.ExternalLinks.Links = append(a.Output.ExternalLinks.Links, attr.Val)
 					}
-					if linkJobQueue != nil {
-						*linkJobQueue <- attr.Val
-					}
-
-					jsonStr, err := utils.JsonToText(a.Output)
-					if err != nil {
+					// link_found is sent before the job is queued so it always precedes the link_checked of the link
+					found := models.LinkFound{Url: attr.Val, Internal: internal, Tag: tokenData, Attribute: attr.Key}
+					if err := a.emit(models.EventLinkFound, found); err != nil {
 						return err
 					}
-					a.Stream <- *jsonStr
+					// links with other schemes (mailto:, tel:) can not be checked and are not counted as broken
+					if linkJobQueue != nil && a.isCheckable(attr.Val, baseUrl) {
+						select {
+						case *linkJobQueue <- models.LinkJob{Url: attr.Val, Tag: tokenData, Attribute: attr.Key}:
+						case <-a.done():
+							return a.ctx.Err()
+						}
+					}
 				}
 			}
 		}
@@ -218,15 +367,7 @@ func (a *BodyAnalyzer) FindIfLogin(tokenType html.TokenType, token html.Token, l
 	}
 	if loginFlags.IsLoginButton && loginFlags.IsPasswordField && loginFlags.IsTextField && loginFlags.IsForm {
 		a.Output.IsLogin = true
-		if a.Stream != nil {
-			jsonStr, err := utils.JsonToText(a.Output)
-			if err != nil {
-				return err
-			}
-			a.Stream <- *jsonStr
-		}
-
-		return nil
+		return a.emit(models.EventLogin, true)
 	}
 	if tokenType == html.StartTagToken || tokenType == html.SelfClosingTagToken {
 
@@ -288,28 +429,134 @@ func (a *BodyAnalyzer) FindIfLogin(tokenType html.TokenType, token html.Token, l
 	return nil
 }
 
+// line of the token being analyzed starting at 1, for checks reporting where they found something
+func (a *BodyAnalyzer) Line() int {
+	return a.line
+}
+
+// pushes a link of a check into the job queue of the running analysis, done gets the result from the worker
+// once the page is read, before the checks are finalized
+// a url queued by several checks (or also linked by the page) is still only requested once, every job gets its result
+// only meant to be called from the Token method of a check
+func (a *BodyAnalyzer) QueueLink(job models.LinkJob) error {
+	select {
+	case a.linkJobQueue <- job:
+		return nil
+	case <-a.done():
+		return a.ctx.Err()
+	}
+}
+
 // acts as the worker of the job queue
-// checks if the link is available/not , groups them and pushes into the data stream as a text obj
-func (a *BodyAnalyzer) ActiveCheckWorker(baseUrl string, linkJobQueue *chan string) {
-	for link := range *linkJobQueue {
-		link = utils.AddInternalHost(link, baseUrl)
-
-		_, err := a.Fetcher.FetchBody(link)
-		if err != nil {
-			a.muInactiveLinks.Lock()
-			a.Output.InactiveLinks.Count++
-			a.Output.InactiveLinks.Links = append(a.Output.InactiveLinks.Links, link)
-			a.muInactiveLinks.Unlock()
+// checks if the link is available/not , groups them and pushes a link_checked event into the data stream
+// the detailed result of every check is kept in LinkResults so the reason for a dead link is not lost
+// links disallowed by robots.txt are not requested and reported as blocked instead
+// every resolved url is checked once, the jobs of a url already checked or being checked share its result
+// once ctx is done the remaining jobs are drained without being checked
+// the result of a job with a done func is handed to it instead, see QueueLink
+func (a *BodyAnalyzer) ActiveCheckWorker(ctx context.Context, baseUrl string, linkJobQueue *chan models.LinkJob) {
+	for job := range *linkJobQueue {
+		if ctx.Err() != nil {
+			continue
+		}
+		link := utils.AddInternalHost(job.Url, baseUrl)
+		if !a.claimLinkCheck(link, job) {
+			continue
+		}
+
+		var result models.LinkResult
+		if a.Robots.Allowed(link) {
+			checkCtx := ctx
+			if a.Scheduler != nil {
+				// robots.txt is only set when it is respected, its crawl-delay then paces the checks of the host
+				var limiter fetcher.Limiter = a.Scheduler
+				if delay := a.Robots.CrawlDelay(link); delay > 0 {
+					limiter = a.Scheduler.WithDelay(delay)
+				}
+				checkCtx = fetcher.WithLimiter(ctx, limiter)
+			}
+			result = a.Fetcher.CheckLink(checkCtx, link)
+			if ctx.Err() != nil {
+				continue
+			}
 		} else {
-			a.muActiveLinks.Lock()
-			a.Output.ActiveLinks.Count++
-			a.Output.ActiveLinks.Links = append(a.Output.ActiveLinks.Links, link)
-			a.muActiveLinks.Unlock()
+			result = models.LinkResult{ResolvedUrl: link, State: models.LinkStateBlockedByRobots}
 		}
-		jsonStr, err := utils.JsonToText(a.Output)
-		if a.Stream != nil {
-			a.Stream <- *jsonStr
+		for _, job := range a.finishLinkCheck(link, result) {
+			a.deliverLink(job, link, result)
 		}
+	}
+}
+
+// check of a resolved url, jobs queued while it runs wait in pending and get the result once it is done
+type linkCheck struct {
+	done    bool
+	result  models.LinkResult
+	pending []models.LinkJob
+}
+
+// tells if the job has to check the link itself
+// when the link is already checked the job gets the result right away, when it is being checked the job waits for it
+func (a *BodyAnalyzer) claimLinkCheck(link string, job models.LinkJob) bool {
+	a.muLinkChecks.Lock()
+	if a.linkChecks == nil {
+		a.linkChecks = map[string]*linkCheck{}
+	}
+	check, ok := a.linkChecks[link]
+	if !ok {
+		a.linkChecks[link] = &linkCheck{pending: []models.LinkJob{job}}
+		a.muLinkChecks.Unlock()
+		return true
+	}
+	if !check.done {
+		check.pending = append(check.pending, job)
+		a.muLinkChecks.Unlock()
+		return false
+	}
+	result := check.result
+	a.muLinkChecks.Unlock()
+	a.deliverLink(job, link, result)
+	return false
+}
+
+// stores the result of the link and returns the jobs waiting for it
+func (a *BodyAnalyzer) finishLinkCheck(link string, result models.LinkResult) []models.LinkJob {
+	a.muLinkChecks.Lock()
+	defer a.muLinkChecks.Unlock()
+	check := a.linkChecks[link]
+	check.done, check.result = true, result
+	pending := check.pending
+	check.pending = nil
+	return pending
+}
+
+// hands the result of the link to the job, the done func of a check or the page links of the output
+func (a *BodyAnalyzer) deliverLink(job models.LinkJob, link string, result models.LinkResult) {
+	result.Url, result.Tag, result.Attribute = job.Url, job.Tag, job.Attribute
+	if job.Done != nil {
+		job.Done(result)
+		return
+	}
+
+	a.muLinkResults.Lock()
+	a.Output.LinkResults = append(a.Output.LinkResults, result)
+	a.muLinkResults.Unlock()
 
+	if result.State == models.LinkStateBlockedByRobots {
+		a.muBlockedLinks.Lock()
+		a.Output.BlockedLinks.Count++
+		a.Output.BlockedLinks.Links = append(a.Output.BlockedLinks.Links, link)
+		a.muBlockedLinks.Unlock()
+	} else if result.State != models.LinkStateActive {
+		a.muInactiveLinks.Lock()
+		a.Output.InactiveLinks.Count++
+		a.Output.InactiveLinks.Links = append(a.Output.InactiveLinks.Links, link)
+		a.muInactiveLinks.Unlock()
+	} else {
+		a.muActiveLinks.Lock()
+		a.Output.ActiveLinks.Count++
+		a.Output.ActiveLinks.Links = append(a.Output.ActiveLinks.Links, link)
+		a.muActiveLinks.Unlock()
 	}
+	a.emit(models.EventLinkChecked, result)
 }
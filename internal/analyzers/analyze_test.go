@@ -1,9 +1,20 @@
 package analyzers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/RidmaTP/web-analyzer/internal/fetcher"
 	"github.com/RidmaTP/web-analyzer/internal/models"
@@ -17,6 +28,7 @@ func Test_Analyze(t *testing.T) {
 		html          string
 		expectTitle   string
 		expectVersion string
+		expectHeaders int
 		expectErr     bool
 		forceErr      bool
 	}{
@@ -35,6 +47,7 @@ func Test_Analyze(t *testing.T) {
 			`,
 			expectTitle:   "Test Page",
 			expectVersion: "HTML5",
+			expectHeaders: 1,
 			expectErr:     false,
 		},
 	}
@@ -49,15 +62,17 @@ func Test_Analyze(t *testing.T) {
 				Output: models.Output{},
 			}
 
-			err := ba.Analyze("")
+			err := ba.Analyze(context.Background(), "")
 			if (err != nil) != tt.expectErr {
 				t.Fatalf("Analyze() error = %v, expectErr %v", err, tt.expectErr)
 			}
 
 			close(ba.Stream)
 
+			var ops []string
 			var lastMsg string
 			for msg := range ba.Stream {
+				ops = append(ops, decodeEvent(t, msg, nil))
 				lastMsg = msg
 			}
 
@@ -65,12 +80,11 @@ func Test_Analyze(t *testing.T) {
 				t.Fatal("expected msg from stream, got none")
 			}
 
-			var out models.Output
+			var out models.AnalysisResult
 			if lastMsg != "" {
-				erro := json.Unmarshal([]byte(lastMsg), &out)
-				if erro != nil {
-					t.Fatalf("failed to unmarshal last message: %v", err)
-				}
+				assert.Equal(t, models.EventSnapshot, ops[0])
+				assert.Equal(t, models.EventResult, decodeEvent(t, lastMsg, &out))
+				assert.Equal(t, tt.expectHeaders, out.Summary.Headers)
 
 				if out.Title != tt.expectTitle {
 					t.Errorf("expected title %q, got %q", tt.expectTitle, out.Title)
@@ -87,8 +101,10 @@ func Test_Analyze_Err(t *testing.T) {
 		name            string
 		html            string
 		expectErr       bool
+		expectCode      string
 		forceErrFetcher bool
 		forceErrReader  bool
+		readerErr       error
 	}{
 		{
 			name: "Forcing Fetcher error",
@@ -104,8 +120,29 @@ func Test_Analyze_Err(t *testing.T) {
 				</html>
 			`,
 			expectErr:       true,
+			expectCode:      models.ErrCodeFetchFailed,
 			forceErrFetcher: true,
 		},
+		{
+			name:           "Forcing body read error",
+			expectErr:      true,
+			expectCode:     models.ErrCodeFetchFailed,
+			forceErrReader: true,
+		},
+		{
+			name:           "Body read timeout",
+			expectErr:      true,
+			expectCode:     models.ErrCodeFetchTimeout,
+			forceErrReader: true,
+			readerErr:      &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded},
+		},
+		{
+			name:           "Connection reset while reading the body",
+			expectErr:      true,
+			expectCode:     models.ErrCodeConnectionFailed,
+			forceErrReader: true,
+			readerErr:      &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+		},
 	}
 
 	for _, tt := range tests {
@@ -115,36 +152,243 @@ func Test_Analyze_Err(t *testing.T) {
 					ResponseBody:   tt.html,
 					ForceErr:       tt.forceErrFetcher,
 					ForceReaderErr: tt.forceErrReader,
+					ReaderErr:      tt.readerErr,
 				},
 				Stream: make(chan string, 10),
 				Output: models.Output{},
 			}
 
-			err := ba.Analyze("")
+			err := ba.Analyze(context.Background(), "")
 			assert.Equal(t, tt.expectErr, err != nil)
+			if err != nil {
+				assert.Equal(t, tt.expectCode, err.Code)
+			}
 
 			close(ba.Stream)
 
-			var lastMsg string
 			for msg := range ba.Stream {
-				lastMsg = msg
+				assert.NotEqual(t, models.EventResult, decodeEvent(t, msg, nil), "failed analysis must not send a result")
 			}
+		})
+	}
+}
 
-			if lastMsg == "" && !tt.expectErr {
-				t.Fatal("expected msg from stream, got none")
-			}
+// decodes a stream event into data (when not nil) and returns its op
+func decodeEvent(t *testing.T, msg string, data interface{}) string {
+	var event struct {
+		Op   string
+		Data json.RawMessage
+	}
+	assert.NoError(t, json.Unmarshal([]byte(msg), &event))
+	if data != nil {
+		assert.NoError(t, json.Unmarshal(event.Data, data))
+	}
+	return event.Op
+}
 
-			var out models.Output
-			if lastMsg != "" {
-				erro := json.Unmarshal([]byte(lastMsg), &out)
-				if erro != nil {
-					t.Fatalf("failed to unmarshal last message: %v", err)
-				}
-			}
+func Test_FetchErrOut(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectStatus    int
+		expectCode      string
+		expectRetryable bool
+	}{
+		{
+			name:            "Transient page status",
+			err:             &fetcher.StatusError{StatusCode: 503},
+			expectStatus:    502,
+			expectCode:      models.ErrCodeUpstreamStatus,
+			expectRetryable: true,
+		},
+		{
+			name:         "Page not found",
+			err:          &fetcher.StatusError{StatusCode: 404},
+			expectStatus: 502,
+			expectCode:   models.ErrCodeUpstreamStatus,
+		},
+		{
+			name:         "DNS failure",
+			err:          &url.Error{Op: "Get", URL: "https://nope.invalid", Err: &net.DNSError{Err: "no such host", Name: "nope.invalid"}},
+			expectStatus: 502,
+			expectCode:   models.ErrCodeDNSFailure,
+		},
+		{
+			name:            "Fetch timeout",
+			err:             &url.Error{Op: "Get", URL: "https://lucytech.se", Err: os.ErrDeadlineExceeded},
+			expectStatus:    504,
+			expectCode:      models.ErrCodeFetchTimeout,
+			expectRetryable: true,
+		},
+		{
+			name:         "Unknown",
+			err:          errors.New("something else"),
+			expectStatus: 502,
+			expectCode:   models.ErrCodeFetchFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errObj := fetchErrOut(tt.err)
+			assert.Equal(t, tt.expectStatus, errObj.StatusCode)
+			assert.Equal(t, tt.expectCode, errObj.Code)
+			assert.Equal(t, tt.expectRetryable, errObj.Retryable)
+			assert.Equal(t, tt.err.Error(), errObj.Error)
 		})
 	}
 }
 
+func Test_Analyze_Cancel(t *testing.T) {
+	ba := BodyAnalyzer{
+		Fetcher: &fetcher.MockFetcher{
+			ResponseBody: `<html><head><title>Test Page</title></head><body><a href="/a">a</a><a href="/b">b</a></body></html>`,
+		},
+		// nobody reads the stream, sends must give up once the context is done
+		Stream:  make(chan string),
+		Output:  models.Output{},
+		Workers: 2,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan *models.ErrorOut)
+	go func() {
+		done <- ba.Analyze(ctx, "https://lucytech.se/")
+	}()
+
+	select {
+	case errObj := <-done:
+		if assert.NotNil(t, errObj) {
+			assert.Equal(t, 504, errObj.StatusCode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("analysis was not stopped")
+	}
+}
+
+// counts the link checks of every url
+type countingFetcher struct {
+	fetcher.MockFetcher
+	mu     sync.Mutex
+	checks map[string]int
+}
+
+func (f *countingFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	f.mu.Lock()
+	f.checks[url]++
+	f.mu.Unlock()
+	return f.MockFetcher.CheckLink(ctx, url)
+}
+
+func Test_Analyze_LinkChecksOnce(t *testing.T) {
+	page := `<html><head>
+<link rel="stylesheet" href="/main.css">
+<link rel="canonical" href="https://lucytech.se/">
+<script src="/app.js"></script>
+</head><body>
+<a href="/about">About</a>
+<a href="/about">About again</a>
+<a href="/hero.png"><img src="/hero.png" alt="Hero"></a>
+<img src="/missing.png" alt="Missing">
+<script src="/app.js"></script>
+</body></html>`
+	f := &countingFetcher{
+		MockFetcher: fetcher.MockFetcher{Pages: map[string]string{
+			"https://lucytech.se/":         page,
+			"https://lucytech.se/main.css": "",
+			"https://lucytech.se/app.js":   "",
+			"https://lucytech.se/about":    "",
+			"https://lucytech.se/hero.png": "",
+		}},
+		checks: map[string]int{},
+	}
+	a := BodyAnalyzer{
+		Fetcher: f,
+		Stream:  make(chan string, 100),
+		Workers: 4,
+		Checks:  []string{"links", "seo", "images", "resources"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	assert.Equal(t, map[string]int{
+		"https://lucytech.se/":            1,
+		"https://lucytech.se/main.css":    1,
+		"https://lucytech.se/app.js":      1,
+		"https://lucytech.se/about":       1,
+		"https://lucytech.se/hero.png":    1,
+		"https://lucytech.se/missing.png": 1,
+	}, f.checks)
+	// every link of the page still gets its own result
+	assert.Len(t, a.Output.LinkResults, 5)
+	assert.Equal(t, 5, a.Output.ActiveLinks.Count)
+	// and the checks get the shared result
+	images := a.Output.Sections["images"].(models.ImagesSection)
+	assert.Equal(t, models.LinkStateActive, images.Images[0].Check.State)
+	assert.Equal(t, 1, images.Broken)
+	seo := a.Output.Sections["seo"].(models.SeoSection)
+	assert.True(t, seo.CanonicalSelf)
+}
+
+// the page body is read as fast as its links are checked, which can take longer than the total timeout of a link check
+// raw html without a base url resolves its relative links to file:// urls, a remote fetcher can not check them
+func Test_Analyze_RawHtmlWithoutBaseUrl(t *testing.T) {
+	page := `<html><head><title>Upload</title></head><body>
+<a href="/about">About</a>
+<a href="https://lucytech.se/">Home</a>
+<img src="/logo.png" alt="Logo">
+</body></html>`
+	remote := &countingFetcher{
+		MockFetcher: fetcher.MockFetcher{Pages: map[string]string{"https://lucytech.se/": ""}},
+		checks:      map[string]int{},
+	}
+	a := BodyAnalyzer{
+		Fetcher: &fetcher.ReaderFetcher{Url: fetcher.RawPageUrl, Html: []byte(page), Remote: remote},
+		Stream:  make(chan string, 100),
+		Workers: 2,
+		Checks:  []string{"links", "images"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), fetcher.RawPageUrl))
+
+	assert.Equal(t, map[string]int{"https://lucytech.se/": 1}, remote.checks)
+	assert.Equal(t, []string{"/about"}, a.Output.InternalLinks.Links)
+	assert.Equal(t, 1, a.Output.ActiveLinks.Count)
+	assert.Equal(t, 0, a.Output.InactiveLinks.Count)
+	assert.Len(t, a.Output.LinkResults, 1)
+}
+
+func Test_Analyze_SlowLinkChecks(t *testing.T) {
+	var page strings.Builder
+	page.WriteString("<html><head><title>Links</title></head><body>")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&page, `<p>%s</p><a href="/page/%d">page %d</a>`, strings.Repeat("text ", 50), i, i)
+	}
+	page.WriteString("</body></html>")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(page.String()))
+		}
+	}))
+	defer server.Close()
+
+	a := BodyAnalyzer{
+		Fetcher: fetcher.NewFetcher(models.FetcherConfig{
+			ConnectTimeout: time.Second,
+			ReadTimeout:    time.Second,
+			TotalTimeout:   200 * time.Millisecond,
+		}),
+		// a single request per host every 5ms, the 200 link checks take about a second
+		Scheduler: NewHostScheduler(models.SchedulerConfig{MaxPerHost: 1, HostDelay: 5 * time.Millisecond}),
+		Stream:    make(chan string, 500),
+		Workers:   2,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.Nil(t, a.Analyze(ctx, server.URL+"/"))
+	assert.Equal(t, "Links", a.Output.Title)
+	assert.Equal(t, 200, a.Output.ActiveLinks.Count)
+}
+
 func Test_FindTitle(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -218,10 +462,9 @@ func Test_FindTitle(t *testing.T) {
 			if tt.isStream {
 				select {
 				case msg := <-ba.Stream:
-					var out models.Output
-					err := json.Unmarshal([]byte(msg), &out)
-					assert.NoError(t, err)
-					assert.Equal(t, tt.isTitle, out.Title, "expected "+tt.existingTitle+", got "+out.Title)
+					var title string
+					assert.Equal(t, models.EventTitle, decodeEvent(t, msg, &title))
+					assert.Equal(t, tt.isTitle, title, "expected "+tt.isTitle+", got "+title)
 				default:
 					t.Error("expected message on Stream, but none found")
 				}
@@ -294,10 +537,9 @@ func Test_FindHTMLVersion(t *testing.T) {
 			if tt.isStream {
 				select {
 				case msg := <-ba.Stream:
-					var out models.Output
-					err := json.Unmarshal([]byte(msg), &out)
-					assert.NoError(t, err)
-					assert.Equal(t, tt.expectVersion, out.Version)
+					var version string
+					assert.Equal(t, models.EventVersion, decodeEvent(t, msg, &version))
+					assert.Equal(t, tt.expectVersion, version)
 				default:
 					t.Error("expected message on Stream, but none found")
 				}
@@ -362,10 +604,9 @@ func Test_FindHeaderCount(t *testing.T) {
 			if tt.isStream {
 				select {
 				case msg := <-stream:
-					var out models.Output
-					err := json.Unmarshal([]byte(msg), &out)
-					assert.NoError(t, err)
-					assert.Equal(t, tt.expected, ba.Output.Headers)
+					var header models.HeaderCount
+					assert.Equal(t, models.EventHeader, decodeEvent(t, msg, &header))
+					assert.Equal(t, tt.expected[header.Tag], header.Count)
 				default:
 					assert.Fail(t, "expected message on Stream, but none found")
 				}
@@ -457,7 +698,7 @@ func Test_FindLinks(t *testing.T) {
 				muActiveLinks:   sync.Mutex{},
 				wg:              &sync.WaitGroup{},
 			}
-			jobs := make(chan string, 10)
+			jobs := make(chan models.LinkJob, 10)
 			err := ba.FindLinks(tt.tokenType, tt.token, tt.baseurl, &jobs)
 			assert.NoError(t, err)
 			if tt.isExternal {
@@ -469,14 +710,11 @@ func Test_FindLinks(t *testing.T) {
 			if tt.isStream {
 				select {
 				case msg := <-stream:
-					var out models.Output
-					err := json.Unmarshal([]byte(msg), &out)
-					assert.NoError(t, err)
-					if tt.isExternal {
-						assert.Equal(t, tt.expected, ba.Output.ExternalLinks)
-					} else {
-						assert.Equal(t, tt.expected, ba.Output.InternalLinks)
-					}
+					var found models.LinkFound
+					assert.Equal(t, models.EventLinkFound, decodeEvent(t, msg, &found))
+					assert.Equal(t, tt.expected.Links[len(tt.expected.Links)-1], found.Url)
+					assert.Equal(t, !tt.isExternal, found.Internal)
+					assert.Equal(t, tt.token.Data, found.Tag)
 				default:
 					assert.Fail(t, "expected message on Stream, but none found")
 				}
@@ -581,22 +819,44 @@ func Test_ActiveCheckerWorker(t *testing.T) {
 	tests := []struct {
 		name          string
 		url           string
-		jobQueue      chan string
+		jobQueue      chan models.LinkJob
 		expected      models.Output
 		isUrlInactive bool
 	}{
 		{
-			name:          "Accessible URL",
-			url:           "https://lucytech.se/",
-			jobQueue:      make(chan string, 1),
-			expected:      models.Output{ActiveLinks: models.LinksData{Count: 1, Links: []string{"https://lucytech.se/"}}},
+			name:     "Accessible URL",
+			url:      "https://lucytech.se/",
+			jobQueue: make(chan models.LinkJob, 1),
+			expected: models.Output{
+				ActiveLinks: models.LinksData{Count: 1, Links: []string{"https://lucytech.se/"}},
+				LinkResults: []models.LinkResult{{
+					Url:         "https://lucytech.se/",
+					ResolvedUrl: "https://lucytech.se/",
+					FinalUrl:    "https://lucytech.se/",
+					State:       models.LinkStateActive,
+					StatusCode:  200,
+					Tag:         "a",
+					Attribute:   "href",
+				}},
+			},
 			isUrlInactive: false,
 		},
 		{
-			name:          "Inaccessible URL",
-			url:           "https://lucytech.se/",
-			jobQueue:      make(chan string, 1),
-			expected:      models.Output{InactiveLinks: models.LinksData{Count: 1, Links: []string{"https://lucytech.se/"}}},
+			name:     "Inaccessible URL",
+			url:      "https://lucytech.se/",
+			jobQueue: make(chan models.LinkJob, 1),
+			expected: models.Output{
+				InactiveLinks: models.LinksData{Count: 1, Links: []string{"https://lucytech.se/"}},
+				LinkResults: []models.LinkResult{{
+					Url:         "https://lucytech.se/",
+					ResolvedUrl: "https://lucytech.se/",
+					State:       models.LinkStateInactive,
+					ErrorClass:  fetcher.ErrClassUnknown,
+					Error:       "mock err",
+					Tag:         "a",
+					Attribute:   "href",
+				}},
+			},
 			isUrlInactive: true,
 		},
 	}
@@ -609,16 +869,39 @@ func Test_ActiveCheckerWorker(t *testing.T) {
 				Stream:  make(chan string, 1),
 			}
 
-			go analyzer.ActiveCheckWorker(tt.url, &tt.jobQueue)
+			go analyzer.ActiveCheckWorker(context.Background(), tt.url, &tt.jobQueue)
 
-			tt.jobQueue <- tt.url
+			tt.jobQueue <- models.LinkJob{Url: tt.url, Tag: "a", Attribute: "href"}
 			close(tt.jobQueue)
-			var out models.Output
+			var result models.LinkResult
 			msg := <-analyzer.Stream
-			err := json.Unmarshal([]byte(msg), &out)
-			assert.NoError(t, err)
+			assert.Equal(t, models.EventLinkChecked, decodeEvent(t, msg, &result))
 
-			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.expected.LinkResults[0], result)
+			assert.Equal(t, tt.expected, analyzer.Output)
 		})
 	}
 }
+
+func Test_ActiveCheckerWorker_Robots(t *testing.T) {
+	mock := &fetcher.MockFetcher{Pages: map[string]string{
+		"https://lucytech.se/robots.txt": "User-agent: *\nDisallow: /private",
+		"https://lucytech.se/about":      "",
+	}}
+	analyzer := &BodyAnalyzer{
+		Output:  models.Output{},
+		Fetcher: mock,
+		Robots:  fetcher.NewRobotsCache(mock, "web-analyzer", time.Hour),
+	}
+
+	jobQueue := make(chan models.LinkJob, 2)
+	jobQueue <- models.LinkJob{Url: "/private/page", Tag: "a", Attribute: "href"}
+	jobQueue <- models.LinkJob{Url: "/about", Tag: "a", Attribute: "href"}
+	close(jobQueue)
+	analyzer.ActiveCheckWorker(context.Background(), "https://lucytech.se/", &jobQueue)
+
+	assert.Equal(t, models.LinksData{Count: 1, Links: []string{"https://lucytech.se/private/page"}}, analyzer.Output.BlockedLinks)
+	assert.Equal(t, models.LinksData{Count: 1, Links: []string{"https://lucytech.se/about"}}, analyzer.Output.ActiveLinks)
+	assert.Equal(t, 0, analyzer.Output.InactiveLinks.Count)
+	assert.Equal(t, models.LinkStateBlockedByRobots, analyzer.Output.LinkResults[0].State)
+}
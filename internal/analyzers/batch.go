@@ -0,0 +1,115 @@
+package analyzers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+)
+
+// batch analyzer configuration fields
+// every url is analyzed with its own BodyAnalyzer, slots bounds the analyses running at the same time
+// slots is meant to be shared by all the batches so the bound holds for the whole service, nil means one at a time
+// stream receives a url_result event for every url in the order the analyses finish
+// robots makes the link checker of every page respect robots.txt, nil means robots.txt is not checked
+type BatchAnalyzer struct {
+	Fetcher   fetcher.BodyFetcher
+	Scheduler *HostScheduler
+	Robots    *fetcher.RobotsCache
+	Stream    chan string
+	Output    models.BatchSummary
+	Workers   int
+	Slots     chan struct{}
+	mu        sync.Mutex
+}
+
+// analyzes every url of the batch, invalid urls are reported as failed without being fetched
+// stops with an error once ctx is done, the urls analyzed so far are already streamed
+func (b *BatchAnalyzer) Analyze(ctx context.Context, urls []string) *models.ErrorOut {
+	start := time.Now()
+	b.Output.UrlsTotal = len(urls)
+	slots := b.Slots
+	if slots == nil {
+		slots = make(chan struct{}, 1)
+	}
+	pages := pageAnalyzer{Fetcher: b.Fetcher, Scheduler: b.Scheduler, Robots: b.Robots, Workers: b.Workers}
+	brokenLinks := map[string]bool{}
+
+	wg := sync.WaitGroup{}
+	for i, url := range urls {
+		if errObj := utils.UrlValidationCheck(&url); errObj != nil {
+			b.addItem(ctx, models.BatchItem{Index: i, Url: url, Error: errObj}, brokenLinks)
+			continue
+		}
+		acquired := false
+		select {
+		case slots <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// select picks at random when ctx is done and a slot is free, the slot is shared and must be released
+			if acquired {
+				<-slots
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			page, _, errObj := pages.analyze(ctx, url, 0)
+			if ctx.Err() != nil {
+				return
+			}
+			item := models.BatchItem{Index: i, Url: url, Error: errObj}
+			if errObj == nil {
+				item.Output = &page.Output
+			}
+			b.addItem(ctx, item, brokenLinks)
+		}(i, url)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return contextErrOut(ctx.Err())
+	}
+	b.Output.DurationMs = time.Since(start).Milliseconds()
+	return nil
+}
+
+// adds the item to the summary and streams it
+func (b *BatchAnalyzer) addItem(ctx context.Context, item models.BatchItem, brokenLinks map[string]bool) {
+	b.mu.Lock()
+	summary := &b.Output
+	if item.Error != nil {
+		summary.Failed++
+	} else {
+		summary.Analyzed++
+		for _, link := range item.Output.InactiveLinks.Links {
+			if !brokenLinks[link] {
+				brokenLinks[link] = true
+				summary.TotalBrokenLinks++
+			}
+		}
+		if item.Output.Title == "" {
+			summary.PagesWithoutTitle = append(summary.PagesWithoutTitle, item.Url)
+		}
+		if item.Output.IsLogin {
+			summary.LoginPages = append(summary.LoginPages, item.Url)
+		}
+	}
+	jsonStr, err := utils.JsonToText(models.StreamEvent{Op: models.EventUrlResult, Data: item})
+	b.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	select {
+	case b.Stream <- *jsonStr:
+	case <-ctx.Done():
+	}
+}
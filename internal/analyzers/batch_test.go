@@ -0,0 +1,84 @@
+package analyzers
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Batch(t *testing.T) {
+	b := BatchAnalyzer{
+		Fetcher: &fetcher.MockFetcher{Pages: crawlSite},
+		Stream:  make(chan string, 20),
+		Workers: 2,
+		Slots:   make(chan struct{}, 2),
+	}
+	urls := []string{"https://lucytech.se/", "https://lucytech.se/contact", "https://lucytech.se/missing", "not a url", "https://lucytech.se/about"}
+
+	errObj := b.Analyze(context.Background(), urls)
+	assert.Nil(t, errObj)
+	close(b.Stream)
+
+	items := []models.BatchItem{}
+	for msg := range b.Stream {
+		var item models.BatchItem
+		assert.Equal(t, models.EventUrlResult, decodeEvent(t, msg, &item))
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Index < items[j].Index })
+	assert.Len(t, items, len(urls))
+	for i, item := range items {
+		assert.Equal(t, i, item.Index)
+	}
+	assert.NotNil(t, items[0].Output)
+	assert.Equal(t, "Home", items[0].Output.Title)
+	assert.Nil(t, items[2].Output)
+	assert.Equal(t, models.ErrCodeUpstreamStatus, items[2].Error.Code)
+	assert.Equal(t, models.ErrCodeInvalidUrl, items[3].Error.Code)
+
+	sort.Strings(b.Output.PagesWithoutTitle)
+	assert.Equal(t, 5, b.Output.UrlsTotal)
+	assert.Equal(t, 3, b.Output.Analyzed)
+	assert.Equal(t, 2, b.Output.Failed)
+	// /missing is linked from two pages but counted once, the partner link is not in the mock site
+	assert.Equal(t, 2, b.Output.TotalBrokenLinks)
+	assert.Equal(t, []string{"https://lucytech.se/about"}, b.Output.PagesWithoutTitle)
+	assert.Equal(t, []string{"https://lucytech.se/contact"}, b.Output.LoginPages)
+}
+
+func Test_Batch_Cancel(t *testing.T) {
+	b := BatchAnalyzer{
+		Fetcher: &fetcher.MockFetcher{Pages: crawlSite},
+		Stream:  make(chan string),
+		Workers: 2,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errObj := b.Analyze(ctx, []string{"https://lucytech.se/", "https://lucytech.se/about"})
+	assert.NotNil(t, errObj)
+	assert.Equal(t, models.ErrCodeCancelled, errObj.Code)
+}
+
+func Test_Batch_CancelReleasesSlots(t *testing.T) {
+	slots := make(chan struct{}, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// a free slot and a done ctx are both ready, the select picks one at random
+	for i := 0; i < 20; i++ {
+		b := BatchAnalyzer{
+			Fetcher: &fetcher.MockFetcher{Pages: crawlSite},
+			Stream:  make(chan string),
+			Workers: 2,
+			Slots:   slots,
+		}
+		errObj := b.Analyze(ctx, []string{"https://lucytech.se/", "https://lucytech.se/about"})
+		assert.NotNil(t, errObj)
+		assert.Empty(t, slots)
+	}
+}
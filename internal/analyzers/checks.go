@@ -0,0 +1,194 @@
+package analyzers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// a check run by the BodyAnalyzer while it goes through the tokens of the page
+// token is called for every token in document order, finalize once the page is read and the link checks are done
+// section is the data the check adds to Output.Sections under its registered name, nil adds nothing
+// (the built in checks fill the top level output fields instead)
+type TokenAnalyzer interface {
+	Token(tokenType html.TokenType, token html.Token) error
+	Finalize() error
+	Section() interface{}
+}
+
+// builds a new instance of a check for a single analysis
+// the analyzer gives access to the page url, the output, the event stream and the link checker
+type TokenAnalyzerFactory func(a *BodyAnalyzer) TokenAnalyzer
+
+type registeredCheck struct {
+	name      string
+	factory   TokenAnalyzerFactory
+	byDefault bool
+}
+
+// registered checks in registration order, which is also the order they run in for every token
+// the built in checks wrap the Find methods of the BodyAnalyzer and fill the top level output fields,
+// they are declared here rather than registered from init so they always run first
+var registry = []registeredCheck{
+	{name: "title", factory: func(a *BodyAnalyzer) TokenAnalyzer { return &titleCheck{a: a} }, byDefault: true},
+	{name: "version", factory: func(a *BodyAnalyzer) TokenAnalyzer { return &versionCheck{a: a} }, byDefault: true},
+	{name: "headers", factory: func(a *BodyAnalyzer) TokenAnalyzer { return &headersCheck{a: a} }, byDefault: true},
+	{name: "links", factory: func(a *BodyAnalyzer) TokenAnalyzer { return &linksCheck{a: a} }, byDefault: true},
+	{name: "login", factory: func(a *BodyAnalyzer) TokenAnalyzer { return &loginCheck{a: a} }, byDefault: true},
+}
+
+// adds a check to the registry, checks enabled by default run when a request does not pick its checks
+// meant to be called from init, panics when the name is already registered
+func Register(name string, factory TokenAnalyzerFactory, byDefault bool) {
+	if _, ok := findCheck(name); ok {
+		panic("analyzers: check " + name + " registered twice")
+	}
+	registry = append(registry, registeredCheck{name: name, factory: factory, byDefault: byDefault})
+}
+
+// names of all the registered checks
+func Checks() []string {
+	names := []string{}
+	for _, check := range registry {
+		names = append(names, check.name)
+	}
+	return names
+}
+
+// names of the checks run when a request does not pick its checks
+func DefaultChecks() []string {
+	names := []string{}
+	for _, check := range registry {
+		if check.byDefault {
+			names = append(names, check.name)
+		}
+	}
+	return names
+}
+
+// parses a comma separated list of check names, an empty list means the default checks (nil)
+// unknown names are an error, duplicates are dropped
+func ParseChecks(raw string) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := findCheck(name); !ok {
+			return nil, fmt.Errorf("unknown check %s, available checks: %s", name, strings.Join(Checks(), ","))
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func findCheck(name string) (registeredCheck, bool) {
+	for _, check := range registry {
+		if check.name == name {
+			return check, true
+		}
+	}
+	return registeredCheck{}, false
+}
+
+// a check of a running analysis with its registered name
+type namedCheck struct {
+	name string
+	TokenAnalyzer
+}
+
+// builds the checks of the analysis in registration order, nil checks means the default checks
+func (a *BodyAnalyzer) newChecks() []namedCheck {
+	enabled := map[string]bool{}
+	for _, name := range a.Checks {
+		enabled[name] = true
+	}
+	checks := []namedCheck{}
+	for _, check := range registry {
+		if (a.Checks == nil && check.byDefault) || enabled[check.name] {
+			checks = append(checks, namedCheck{name: check.name, TokenAnalyzer: check.factory(a)})
+		}
+	}
+	return checks
+}
+
+// finalizes the checks and adds their sections to the output, a section event is sent per section
+func (a *BodyAnalyzer) finalizeChecks(checks []namedCheck) error {
+	for _, check := range checks {
+		if err := check.Finalize(); err != nil {
+			return err
+		}
+		section := check.Section()
+		if section == nil {
+			continue
+		}
+		if a.Output.Sections == nil {
+			a.Output.Sections = map[string]interface{}{}
+		}
+		a.Output.Sections[check.name] = section
+		if err := a.emit(models.EventSection, models.Section{Name: check.name, Data: section}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// no finalize step and no section
+type builtinCheck struct{}
+
+func (builtinCheck) Finalize() error      { return nil }
+func (builtinCheck) Section() interface{} { return nil }
+
+type titleCheck struct {
+	builtinCheck
+	a       *BodyAnalyzer
+	inTitle bool
+}
+
+func (c *titleCheck) Token(tokenType html.TokenType, token html.Token) error {
+	inTitle, err := c.a.FindTitle(tokenType, token, c.inTitle)
+	c.inTitle = inTitle
+	return err
+}
+
+type versionCheck struct {
+	builtinCheck
+	a *BodyAnalyzer
+}
+
+func (c *versionCheck) Token(tokenType html.TokenType, token html.Token) error {
+	return c.a.FindHTMLVersion(tokenType, token)
+}
+
+type headersCheck struct {
+	builtinCheck
+	a *BodyAnalyzer
+}
+
+func (c *headersCheck) Token(tokenType html.TokenType, token html.Token) error {
+	return c.a.FindHeaderCount(tokenType, token)
+}
+
+type linksCheck struct {
+	builtinCheck
+	a *BodyAnalyzer
+}
+
+func (c *linksCheck) Token(tokenType html.TokenType, token html.Token) error {
+	return c.a.FindLinks(tokenType, token, c.a.url, &c.a.linkJobQueue)
+}
+
+type loginCheck struct {
+	builtinCheck
+	a     *BodyAnalyzer
+	flags models.LoginFlags
+}
+
+func (c *loginCheck) Token(tokenType html.TokenType, token html.Token) error {
+	return c.a.FindIfLogin(tokenType, token, &c.flags)
+}
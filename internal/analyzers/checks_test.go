@@ -0,0 +1,103 @@
+package analyzers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// counts the words of the text tokens, registered for the tests only and not enabled by default
+type wordsCheck struct {
+	words int
+}
+
+func (c *wordsCheck) Token(tokenType html.TokenType, token html.Token) error {
+	if tokenType == html.TextToken {
+		c.words += len(strings.Fields(token.Data))
+	}
+	return nil
+}
+
+func (c *wordsCheck) Finalize() error      { return nil }
+func (c *wordsCheck) Section() interface{} { return c.words }
+
+func init() {
+	Register("test_words", func(a *BodyAnalyzer) TokenAnalyzer { return &wordsCheck{} }, false)
+}
+
+func Test_ParseChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		expect    []string
+		expectErr bool
+	}{
+		{name: "Empty means the defaults", raw: "", expect: nil},
+		{name: "Trims, lower cases and drops duplicates", raw: " title, LINKS,title", expect: []string{"title", "links"}},
+		{name: "Unknown check", raw: "title,nope", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := ParseChecks(tt.raw)
+			assert.Equal(t, tt.expectErr, err != nil)
+			assert.Equal(t, tt.expect, checks)
+		})
+	}
+	assert.Equal(t, []string{"title", "version", "headers", "links", "login"}, DefaultChecks()[:5])
+	assert.NotContains(t, DefaultChecks(), "test_words")
+	assert.Panics(t, func() { Register("title", nil, false) })
+}
+
+func Test_Analyze_Checks(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Test Page</title></head>
+		<body><h1>Welcome home</h1><a href="/about">About</a></body></html>`
+	tests := []struct {
+		name           string
+		checks         []string
+		expectTitle    string
+		expectVersion  string
+		expectLinks    int
+		expectSections []string
+	}{
+		{name: "Default checks", checks: nil, expectTitle: "Test Page", expectVersion: "HTML5", expectLinks: 1, expectSections: []string{"a11y", "outline", "seo"}},
+		{name: "Only the title", checks: []string{"title"}, expectTitle: "Test Page"},
+		{name: "Registered check adds a section", checks: []string{"links", "test_words"}, expectLinks: 1, expectSections: []string{"test_words"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := BodyAnalyzer{
+				Fetcher: &fetcher.MockFetcher{ResponseBody: page},
+				Stream:  make(chan string, 20),
+				Workers: 1,
+				Checks:  tt.checks,
+			}
+			assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+			close(a.Stream)
+
+			var sections []string
+			for msg := range a.Stream {
+				if decodeEvent(t, msg, nil) == models.EventSection {
+					var section models.Section
+					decodeEvent(t, msg, &section)
+					sections = append(sections, section.Name)
+				}
+			}
+			assert.Equal(t, tt.expectTitle, a.Output.Title)
+			assert.Equal(t, tt.expectVersion, a.Output.Version)
+			assert.Equal(t, tt.expectLinks, a.Output.InternalLinks.Count)
+			assert.Equal(t, tt.expectSections, sections)
+			assert.Len(t, a.Output.Sections, len(tt.expectSections))
+			if slices.Contains(tt.expectSections, "test_words") {
+				assert.Equal(t, 5, a.Output.Sections["test_words"])
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package analyzers
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+)
+
+// crawler configuration fields
+// the crawler follows the internal links of the a elements found by FindLinks breadth first
+// links the link checker found to be something else than html (images, pdfs) are not followed
+// maxDepth is the number of link hops followed from the start url, maxPages is the page budget
+// stream receives the result of every analyzed page together with the site wide summary
+// robots is always respected for the crawled pages, robotsLinks makes the link checker of every page respect it too
+type Crawler struct {
+	Fetcher     fetcher.BodyFetcher
+	Scheduler   *HostScheduler
+	Robots      *fetcher.RobotsCache
+	RobotsLinks bool
+	Stream      chan string
+	Output      models.CrawlOutput
+	Workers     int
+	MaxDepth    int
+	MaxPages    int
+}
+
+type crawlTarget struct {
+	url   string
+	depth int
+}
+
+// runs the full BodyAnalyzer on every page of the site reachable within the depth and page limits
+// a failure on the start url is returned as an error, failures on other pages are reported in the page result
+// pages disallowed by robots.txt are skipped and the crawl-delay of the site is waited between pages
+// the crawl stops with an error once ctx is done, the pages analyzed so far are already streamed
+func (c *Crawler) Crawl(ctx context.Context, url string) *models.ErrorOut {
+	if !c.Robots.Allowed(url) {
+		return &models.ErrorOut{StatusCode: http.StatusForbidden, Code: models.ErrCodeRobotsDisallowed, Error: "url is disallowed by robots.txt"}
+	}
+	var crawlDelay time.Duration
+	if c.Robots != nil {
+		crawlDelay = c.Robots.Rules(url).CrawlDelay
+	}
+
+	visited := map[string]bool{utils.NormalizeUrl(url): true}
+	brokenLinks := map[string]bool{}
+	queue := []crawlTarget{{url: url}}
+
+	for len(queue) > 0 && c.Output.Summary.PagesAnalyzed < c.MaxPages {
+		target := queue[0]
+		queue = queue[1:]
+
+		if target.url != url {
+			if err := fetcher.SleepContext(ctx, crawlDelay); err != nil {
+				return contextErrOut(err)
+			}
+		}
+		page, anchors, errObj := c.pageAnalyzer().analyze(ctx, target.url, target.depth)
+		if ctx.Err() != nil {
+			return contextErrOut(ctx.Err())
+		}
+		if errObj != nil && target.url == url {
+			return errObj
+		}
+		c.addToSummary(page, brokenLinks)
+
+		if page.Error == "" && target.depth < c.MaxDepth {
+			contentTypes := linkContentTypes(page.Output.LinkResults)
+			for _, link := range anchors {
+				next := utils.ResolveUrl(link, target.url)
+				if next == "" || !utils.IsSameSite(next, url) {
+					continue
+				}
+				key := utils.NormalizeUrl(next)
+				if visited[key] || !isHtml(contentTypes[key]) {
+					continue
+				}
+				visited[key] = true
+				if !c.Robots.Allowed(next) {
+					c.Output.Summary.PagesBlocked = append(c.Output.Summary.PagesBlocked, next)
+					continue
+				}
+				queue = append(queue, crawlTarget{url: next, depth: target.depth + 1})
+			}
+		}
+
+		c.Output.Page = &page
+		jsonStr, err := utils.JsonToText(c.Output)
+		if err != nil {
+			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Code: models.ErrCodeInternal, Error: err.Error()}
+		}
+		select {
+		case c.Stream <- *jsonStr:
+		case <-ctx.Done():
+			return contextErrOut(ctx.Err())
+		}
+	}
+	return nil
+}
+
+// content types of the checked links by normalized url
+func linkContentTypes(results []models.LinkResult) map[string]string {
+	contentTypes := map[string]string{}
+	for _, result := range results {
+		if result.ContentType != "" {
+			contentTypes[utils.NormalizeUrl(result.ResolvedUrl)] = result.ContentType
+		}
+	}
+	return contentTypes
+}
+
+// tells if the content type is an html page, an unknown (empty) content type is assumed to be one
+func isHtml(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+func (c *Crawler) pageAnalyzer() pageAnalyzer {
+	p := pageAnalyzer{Fetcher: c.Fetcher, Scheduler: c.Scheduler, Workers: c.Workers}
+	if c.RobotsLinks {
+		p.Robots = c.Robots
+	}
+	return p
+}
+
+// adds the page to the site wide summary
+// broken links are counted once even when they are linked from multiple pages
+func (c *Crawler) addToSummary(page models.CrawlPage, brokenLinks map[string]bool) {
+	summary := &c.Output.Summary
+	summary.PagesAnalyzed++
+	if page.Error != "" {
+		summary.PagesFailed++
+		return
+	}
+	for _, link := range page.Output.InactiveLinks.Links {
+		if !brokenLinks[link] {
+			brokenLinks[link] = true
+			summary.TotalBrokenLinks++
+		}
+	}
+	if page.Output.Title == "" {
+		summary.PagesWithoutTitle = append(summary.PagesWithoutTitle, page.Url)
+	}
+	if page.Output.IsLogin {
+		summary.LoginPages = append(summary.LoginPages, page.Url)
+	}
+}
@@ -0,0 +1,260 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// the stylesheet is an internal link of the start page but not a page of the site
+var crawlSite = map[string]string{
+	"https://lucytech.se/": `
+		<html><head><title>Home</title><link rel="stylesheet" href="/main.css"></head><body>
+			<a href="/about">About</a>
+			<a href="/contact#form">Contact</a>
+			<a href="/missing">Missing</a>
+			<a href="https://www.home24.de/">Partner</a>
+		</body></html>`,
+	"https://lucytech.se/about": `
+		<html><body>
+			<a href="/">Home</a>
+			<a href="/deep">Deep</a>
+		</body></html>`,
+	"https://lucytech.se/contact": `
+		<html><head><title>Contact</title></head><body>
+			<form>
+				<input type="text"/>
+				<input type="password"/>
+				<input type="submit"/>
+			</form>
+			<a href="/missing">Missing</a>
+		</body></html>`,
+	"https://lucytech.se/deep":     `<html><head><title>Deep</title></head></html>`,
+	"https://lucytech.se/main.css": `body { color: black; }`,
+}
+
+func Test_Crawl(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxDepth      int
+		maxPages      int
+		expectPages   []string
+		expectSummary models.CrawlSummary
+	}{
+		{
+			name:        "Only the start page with depth 0",
+			maxDepth:    0,
+			maxPages:    10,
+			expectPages: []string{"https://lucytech.se/"},
+			expectSummary: models.CrawlSummary{
+				PagesAnalyzed:    1,
+				TotalBrokenLinks: 2,
+			},
+		},
+		{
+			name:        "Follows internal links up to depth 1",
+			maxDepth:    1,
+			maxPages:    10,
+			expectPages: []string{"https://lucytech.se/", "https://lucytech.se/about", "https://lucytech.se/contact", "https://lucytech.se/missing"},
+			expectSummary: models.CrawlSummary{
+				PagesAnalyzed:     4,
+				PagesFailed:       1,
+				TotalBrokenLinks:  2,
+				PagesWithoutTitle: []string{"https://lucytech.se/about"},
+				LoginPages:        []string{"https://lucytech.se/contact"},
+			},
+		},
+		{
+			name:        "Stops at the page budget",
+			maxDepth:    5,
+			maxPages:    2,
+			expectPages: []string{"https://lucytech.se/", "https://lucytech.se/about"},
+			expectSummary: models.CrawlSummary{
+				PagesAnalyzed:     2,
+				TotalBrokenLinks:  2,
+				PagesWithoutTitle: []string{"https://lucytech.se/about"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Crawler{
+				Fetcher:  &fetcher.MockFetcher{Pages: crawlSite},
+				Stream:   make(chan string, 20),
+				Workers:  2,
+				MaxDepth: tt.maxDepth,
+				MaxPages: tt.maxPages,
+			}
+
+			errObj := c.Crawl(context.Background(), "https://lucytech.se/")
+			assert.Nil(t, errObj)
+			close(c.Stream)
+
+			var pages []string
+			var last models.CrawlOutput
+			for msg := range c.Stream {
+				last = models.CrawlOutput{}
+				assert.NoError(t, json.Unmarshal([]byte(msg), &last))
+				pages = append(pages, last.Page.Url)
+			}
+			assert.Equal(t, tt.expectPages, pages)
+			assert.Equal(t, tt.expectSummary, last.Summary)
+		})
+	}
+}
+
+// reports the content type of the links by their extension
+type typedFetcher struct {
+	fetcher.MockFetcher
+}
+
+func (f *typedFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	result := f.MockFetcher.CheckLink(ctx, url)
+	result.ContentType = "text/html; charset=utf-8"
+	if strings.HasSuffix(url, ".pdf") {
+		result.ContentType = "application/pdf"
+	}
+	return result
+}
+
+func Test_Crawl_HtmlOnly(t *testing.T) {
+	c := Crawler{
+		Fetcher: &typedFetcher{MockFetcher: fetcher.MockFetcher{Pages: map[string]string{
+			"https://lucytech.se/": `
+				<html><head><title>Home</title></head><body>
+					<a href="/report.pdf">Report</a>
+					<a href="/about">About</a>
+				</body></html>`,
+			"https://lucytech.se/report.pdf": "%PDF-1.7",
+			"https://lucytech.se/about":      `<html><head><title>About</title></head></html>`,
+		}}},
+		Stream:   make(chan string, 20),
+		Workers:  2,
+		MaxDepth: 1,
+		MaxPages: 10,
+	}
+
+	assert.Nil(t, c.Crawl(context.Background(), "https://lucytech.se/"))
+	close(c.Stream)
+
+	var pages []string
+	for msg := range c.Stream {
+		var out models.CrawlOutput
+		assert.NoError(t, json.Unmarshal([]byte(msg), &out))
+		pages = append(pages, out.Page.Url)
+	}
+	assert.Equal(t, []string{"https://lucytech.se/", "https://lucytech.se/about"}, pages)
+}
+
+func Test_Crawl_Hosts(t *testing.T) {
+	mock := &fetcher.MockFetcher{Pages: map[string]string{
+		"https://example.com/": `
+			<html><head><title>Home</title></head><body>
+				<a href="https://notexample.com/x">Look-alike</a>
+				<a href="https://example.com.evil.net/">Suffix</a>
+				<a href="https://blog.example.com/">Blog</a>
+				<a href="mailto:info@example.com">Mail</a>
+				<a href="tel:+46123">Call</a>
+			</body></html>`,
+		"https://notexample.com/x":      `<html><head><title>Other</title></head></html>`,
+		"https://example.com.evil.net/": `<html><head><title>Other</title></head></html>`,
+		"https://blog.example.com/":     `<html><head><title>Blog</title></head></html>`,
+	}}
+	c := Crawler{
+		Fetcher:  mock,
+		Stream:   make(chan string, 20),
+		Workers:  2,
+		MaxDepth: 1,
+		MaxPages: 10,
+	}
+
+	assert.Nil(t, c.Crawl(context.Background(), "https://example.com/"))
+	close(c.Stream)
+
+	var pages []string
+	for msg := range c.Stream {
+		var out models.CrawlOutput
+		assert.NoError(t, json.Unmarshal([]byte(msg), &out))
+		pages = append(pages, out.Page.Url)
+	}
+	assert.Equal(t, []string{"https://example.com/", "https://blog.example.com/"}, pages)
+	// mailto and tel links are not checked so they are not broken
+	assert.Equal(t, 0, c.Output.Summary.TotalBrokenLinks)
+}
+
+func Test_Crawl_Err(t *testing.T) {
+	c := Crawler{
+		Fetcher:  &fetcher.MockFetcher{ForceErr: true},
+		Stream:   make(chan string, 20),
+		Workers:  2,
+		MaxDepth: 1,
+		MaxPages: 10,
+	}
+
+	errObj := c.Crawl(context.Background(), "https://lucytech.se/")
+	assert.NotNil(t, errObj)
+}
+
+func Test_Crawl_Robots(t *testing.T) {
+	pages := map[string]string{"https://lucytech.se/robots.txt": "User-agent: *\nDisallow: /about"}
+	for url, body := range crawlSite {
+		pages[url] = body
+	}
+	mock := &fetcher.MockFetcher{Pages: pages}
+
+	tests := []struct {
+		name          string
+		startUrl      string
+		expectErr     bool
+		expectPages   []string
+		expectBlocked []string
+	}{
+		{
+			name:          "Skips disallowed pages",
+			startUrl:      "https://lucytech.se/",
+			expectPages:   []string{"https://lucytech.se/", "https://lucytech.se/contact", "https://lucytech.se/missing"},
+			expectBlocked: []string{"https://lucytech.se/about"},
+		},
+		{
+			name:      "Disallowed start url",
+			startUrl:  "https://lucytech.se/about",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Crawler{
+				Fetcher:  mock,
+				Robots:   fetcher.NewRobotsCache(mock, "web-analyzer", time.Hour),
+				Stream:   make(chan string, 20),
+				Workers:  2,
+				MaxDepth: 1,
+				MaxPages: 10,
+			}
+
+			errObj := c.Crawl(context.Background(), tt.startUrl)
+			close(c.Stream)
+			assert.Equal(t, tt.expectErr, errObj != nil)
+			if tt.expectErr {
+				return
+			}
+
+			var pages []string
+			for msg := range c.Stream {
+				var out models.CrawlOutput
+				assert.NoError(t, json.Unmarshal([]byte(msg), &out))
+				pages = append(pages, out.Page.Url)
+			}
+			assert.Equal(t, tt.expectPages, pages)
+			assert.Equal(t, tt.expectBlocked, c.Output.Summary.PagesBlocked)
+		})
+	}
+}
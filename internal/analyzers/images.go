@@ -0,0 +1,160 @@
+package analyzers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// images larger than this are reported as oversized
+const maxImageBytes = 500 * 1024
+
+// url() of a css value, used to find background images in style attributes
+var cssUrlPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+func init() {
+	Register("images", func(a *BodyAnalyzer) TokenAnalyzer {
+		return &imagesCheck{a: a, links: newLinkResults(a)}
+	}, false)
+}
+
+// collects the images of the page (img src and srcset, picture sources and css background images in style attributes)
+// keys holds the key of the check result of every image
+type imagesCheck struct {
+	a                 *BodyAnalyzer
+	links             *linkResults
+	images            []models.ImageRef
+	keys              []string
+	inPicture         int
+	missingAlt        int
+	missingDimensions int
+	broken            int
+	oversized         int
+}
+
+func (c *imagesCheck) Token(tokenType html.TokenType, token html.Token) error {
+	switch tokenType {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		switch token.Data {
+		case "picture":
+			if tokenType == html.StartTagToken {
+				c.inPicture++
+			}
+		case "img":
+			if err := c.img(token); err != nil {
+				return err
+			}
+		case "source":
+			if c.inPicture > 0 {
+				for _, url := range srcset(attr(token, "srcset")) {
+					if err := c.add(models.ImageRef{Url: url, Tag: "source", Attribute: "srcset", Line: c.a.Line()}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, match := range cssUrlPattern.FindAllStringSubmatch(attr(token, "style"), -1) {
+			if err := c.add(models.ImageRef{Url: match[1], Tag: token.Data, Attribute: "style", Line: c.a.Line()}); err != nil {
+				return err
+			}
+		}
+	case html.EndTagToken:
+		if token.Data == "picture" && c.inPicture > 0 {
+			c.inPicture--
+		}
+	}
+	return nil
+}
+
+// the alt text and dimensions of an img element are kept on its first url
+func (c *imagesCheck) img(token html.Token) error {
+	var urls []string
+	if src := strings.TrimSpace(attr(token, "src")); src != "" {
+		urls = append(urls, src)
+	}
+	urls = append(urls, srcset(attr(token, "srcset"))...)
+	if len(urls) == 0 {
+		return nil
+	}
+
+	first := models.ImageRef{Url: urls[0], Tag: "img", Attribute: "src", Line: c.a.Line(), Alt: attr(token, "alt"), HasAlt: hasAttr(token, "alt"),
+		Width: attr(token, "width"), Height: attr(token, "height")}
+	if attr(token, "src") == "" {
+		first.Attribute = "srcset"
+	}
+	if !first.HasAlt {
+		first.Issues = append(first.Issues, models.ImageIssueMissingAlt)
+		c.missingAlt++
+	}
+	if first.Width == "" || first.Height == "" {
+		first.Issues = append(first.Issues, models.ImageIssueMissingDimensions)
+		c.missingDimensions++
+	}
+	if err := c.add(first); err != nil {
+		return err
+	}
+	for _, url := range urls[1:] {
+		if err := c.add(models.ImageRef{Url: url, Tag: "img", Attribute: "srcset", Line: c.a.Line()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adds an image and queues the check of its url, inline data: images are not checked
+func (c *imagesCheck) add(ref models.ImageRef) error {
+	key, err := c.links.queue(ref.Url, ref.Tag, ref.Attribute)
+	c.images = append(c.images, ref)
+	c.keys = append(c.keys, key)
+	return err
+}
+
+// broken and oversized images are counted once per url
+func (c *imagesCheck) Finalize() error {
+	broken, oversized := map[string]bool{}, map[string]bool{}
+	for i := range c.images {
+		image := &c.images[i]
+		image.Check = c.links.result(c.keys[i])
+		if image.Check == nil {
+			continue
+		}
+		if image.Check.State == models.LinkStateInactive {
+			image.Issues = append(image.Issues, models.ImageIssueBroken)
+			broken[c.keys[i]] = true
+		}
+		if image.Check.ContentLength > maxImageBytes {
+			image.Issues = append(image.Issues, models.ImageIssueOversized)
+			oversized[c.keys[i]] = true
+		}
+	}
+	c.broken, c.oversized = len(broken), len(oversized)
+	return nil
+}
+
+func (c *imagesCheck) Section() interface{} {
+	images := c.images
+	if images == nil {
+		images = []models.ImageRef{}
+	}
+	return models.ImagesSection{
+		Images:            images,
+		Total:             len(images),
+		Broken:            c.broken,
+		MissingAlt:        c.missingAlt,
+		MissingDimensions: c.missingDimensions,
+		Oversized:         c.oversized,
+	}
+}
+
+// urls of the candidates of a srcset ("a.png 1x, b.png 2x")
+func srcset(value string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(value, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
@@ -0,0 +1,83 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mock fetcher reporting the content length of its pages
+type sizedFetcher struct {
+	fetcher.MockFetcher
+	sizes map[string]int64
+}
+
+func (f *sizedFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	result := f.MockFetcher.CheckLink(ctx, url)
+	result.ContentLength = f.sizes[url]
+	return result
+}
+
+func Test_Images(t *testing.T) {
+	page := `<html><body>
+<img src="/hero.png" alt="Hero" width="800" height="400">
+<img src="/missing.png" srcset="/hero.png 1x, /hero@2x.png 2x">
+<picture>
+	<source srcset="/hero.webp">
+	<img src="data:image/png;base64,AAAA" alt="">
+</picture>
+<video><source src="/movie.mp4"></video>
+<div style="background-image: url('/bg.jpg')"></div>
+</body></html>`
+	f := &sizedFetcher{
+		MockFetcher: fetcher.MockFetcher{Pages: map[string]string{
+			"https://lucytech.se/":            page,
+			"https://lucytech.se/hero.png":    "",
+			"https://lucytech.se/hero@2x.png": "",
+			"https://lucytech.se/hero.webp":   "",
+			"https://lucytech.se/bg.jpg":      "",
+		}},
+		sizes: map[string]int64{"https://lucytech.se/bg.jpg": 2 << 20},
+	}
+	a := BodyAnalyzer{
+		Fetcher: f,
+		Stream:  make(chan string, 50),
+		Workers: 2,
+		Checks:  []string{"images"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	images := a.Output.Sections["images"].(models.ImagesSection)
+	type found struct {
+		Url       string
+		Attribute string
+		Line      int
+		Issues    []string
+	}
+	var refs []found
+	for _, image := range images.Images {
+		refs = append(refs, found{image.Url, image.Attribute, image.Line, image.Issues})
+	}
+	assert.Equal(t, []found{
+		{"/hero.png", "src", 2, nil},
+		{"/missing.png", "src", 3, []string{models.ImageIssueMissingAlt, models.ImageIssueMissingDimensions, models.ImageIssueBroken}},
+		{"/hero.png", "srcset", 3, nil},
+		{"/hero@2x.png", "srcset", 3, nil},
+		{"/hero.webp", "srcset", 5, nil},
+		{"data:image/png;base64,AAAA", "src", 6, []string{models.ImageIssueMissingDimensions}},
+		{"/bg.jpg", "style", 9, []string{models.ImageIssueOversized}},
+	}, refs)
+	assert.Equal(t, 7, images.Total)
+	assert.Equal(t, 1, images.Broken)
+	assert.Equal(t, 1, images.MissingAlt)
+	assert.Equal(t, 2, images.MissingDimensions)
+	assert.Equal(t, 1, images.Oversized)
+
+	assert.Equal(t, models.LinkStateActive, images.Images[0].Check.State)
+	assert.Equal(t, int64(2<<20), images.Images[6].Check.ContentLength)
+	// inline images are not checked
+	assert.Nil(t, images.Images[5].Check)
+}
@@ -0,0 +1,44 @@
+package analyzers
+
+import (
+	"sync"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+)
+
+// link checks requested by a check through QueueLink, keyed by the resolved url
+// every url is queued once, the results are filled by the workers and must only be read from Finalize,
+// once the link checks are done
+type linkResults struct {
+	a       *BodyAnalyzer
+	queued  map[string]bool
+	results map[string]*models.LinkResult
+	mu      sync.Mutex
+}
+
+func newLinkResults(a *BodyAnalyzer) *linkResults {
+	return &linkResults{a: a, queued: map[string]bool{}, results: map[string]*models.LinkResult{}}
+}
+
+// queues the link unless it was queued before or can not be requested (data:, javascript:, mailto: urls)
+// returns the key of its result
+func (l *linkResults) queue(link, tag, attribute string) (string, error) {
+	key := utils.AddInternalHost(link, l.a.url)
+	if l.queued[key] || !l.a.isCheckable(link, l.a.url) {
+		return key, nil
+	}
+	l.queued[key] = true
+	return key, l.a.QueueLink(models.LinkJob{Url: link, Tag: tag, Attribute: attribute, Done: func(result models.LinkResult) {
+		l.mu.Lock()
+		l.results[key] = &result
+		l.mu.Unlock()
+	}})
+}
+
+// check result of the key returned by queue, nil for links that were not checked
+func (l *linkResults) result(key string) *models.LinkResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.results[key]
+}
@@ -0,0 +1,72 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// checks the href of every a element through linkResults, registered for the tests only and not enabled by default
+type hrefsCheck struct {
+	links   *linkResults
+	keys    []string
+	results []*models.LinkResult
+}
+
+func (c *hrefsCheck) Token(tokenType html.TokenType, token html.Token) error {
+	if tokenType != html.StartTagToken || token.Data != "a" {
+		return nil
+	}
+	key, err := c.links.queue(attr(token, "href"), "a", "href")
+	c.keys = append(c.keys, key)
+	return err
+}
+
+func (c *hrefsCheck) Finalize() error {
+	for _, key := range c.keys {
+		c.results = append(c.results, c.links.result(key))
+	}
+	return nil
+}
+
+func (c *hrefsCheck) Section() interface{} { return c.results }
+
+func init() {
+	Register("test_hrefs", func(a *BodyAnalyzer) TokenAnalyzer { return &hrefsCheck{links: newLinkResults(a)} }, false)
+}
+
+func Test_LinkResults(t *testing.T) {
+	page := `<html><body>
+		<a href="/about">About</a>
+		<a href="https://lucytech.se/about">About again</a>
+		<a href="/missing">Missing</a>
+		<a href="mailto:info@lucytech.se">Mail</a>
+		<a href="data:text/plain,hello">Data</a>
+	</body></html>`
+	f := &countingFetcher{
+		MockFetcher: fetcher.MockFetcher{Pages: map[string]string{
+			"https://lucytech.se/":      page,
+			"https://lucytech.se/about": "",
+		}},
+		checks: map[string]int{},
+	}
+	a := BodyAnalyzer{Fetcher: f, Stream: make(chan string, 50), Workers: 2, Checks: []string{"test_hrefs"}}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	results := a.Output.Sections["test_hrefs"].([]*models.LinkResult)
+	assert.Len(t, results, 5)
+	// both references of the about page share the result of a single check
+	assert.Equal(t, models.LinkStateActive, results[0].State)
+	assert.Same(t, results[0], results[1])
+	assert.Equal(t, models.LinkStateInactive, results[2].State)
+	// links that can not be requested are not checked
+	assert.Nil(t, results[3])
+	assert.Nil(t, results[4])
+	assert.Equal(t, map[string]int{"https://lucytech.se/about": 1, "https://lucytech.se/missing": 1}, f.checks)
+	// the links of a check are not page links
+	assert.Empty(t, a.Output.LinkResults)
+}
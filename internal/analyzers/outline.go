@@ -0,0 +1,166 @@
+package analyzers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+func init() {
+	Register("outline", func(a *BodyAnalyzer) TokenAnalyzer {
+		return &outlineCheck{section: models.OutlineSection{Headings: []models.OutlineHeading{}, Issues: []models.OutlineIssue{}}}
+	}, true)
+}
+
+// an open hidden element, depth counts the elements with the same tag opened inside it
+// so the end tag closing the hidden element can be told apart from the end tags of its children
+type hiddenElement struct {
+	tag   string
+	depth int
+}
+
+// builds the document outline from the h1 to h6 headings
+// the text of a heading is the text inside it, including the alt text of its images
+// the tokenizer gives no element tree, so hidden ancestors are tracked by their tag only
+type outlineCheck struct {
+	section models.OutlineSection
+	heading *models.OutlineHeading
+	text    []string
+	hidden  []hiddenElement
+}
+
+func (c *outlineCheck) Token(tokenType html.TokenType, token html.Token) error {
+	switch tokenType {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		if level := headingLevel(token.Data); level > 0 {
+			c.closeHeading()
+			c.heading = &models.OutlineHeading{Order: len(c.section.Headings) + 1, Level: level, Hidden: c.inHidden() || isHidden(token)}
+		} else if c.heading != nil && token.Data == "img" {
+			c.text = append(c.text, attr(token, "alt"))
+		}
+		if tokenType == html.StartTagToken {
+			c.openElement(token)
+		}
+	case html.EndTagToken:
+		if headingLevel(token.Data) > 0 {
+			c.closeHeading()
+		}
+		c.closeElement(token.Data)
+	case html.TextToken:
+		if c.heading != nil {
+			c.text = append(c.text, token.Data)
+		}
+	}
+	return nil
+}
+
+func (c *outlineCheck) openElement(token html.Token) {
+	if n := len(c.hidden); n > 0 && c.hidden[n-1].tag == token.Data {
+		c.hidden[n-1].depth++
+		return
+	}
+	if isHidden(token) && !voidElements[token.Data] {
+		c.hidden = append(c.hidden, hiddenElement{tag: token.Data})
+	}
+}
+
+func (c *outlineCheck) closeElement(tag string) {
+	n := len(c.hidden)
+	if n == 0 || c.hidden[n-1].tag != tag {
+		return
+	}
+	if c.hidden[n-1].depth > 0 {
+		c.hidden[n-1].depth--
+		return
+	}
+	c.hidden = c.hidden[:n-1]
+}
+
+func (c *outlineCheck) inHidden() bool {
+	return len(c.hidden) > 0
+}
+
+// adds the open heading to the outline, whitespace of its text is collapsed
+func (c *outlineCheck) closeHeading() {
+	if c.heading == nil {
+		return
+	}
+	c.heading.Text = strings.Join(strings.Fields(strings.Join(c.text, " ")), " ")
+	c.section.Headings = append(c.section.Headings, *c.heading)
+	c.heading, c.text = nil, nil
+}
+
+// hidden headings are reported but left out of the hierarchy checks since readers never see them
+func (c *outlineCheck) Finalize() error {
+	c.closeHeading()
+	h1s, previous := 0, 0
+	for _, heading := range c.section.Headings {
+		if heading.Text == "" {
+			c.issue(models.OutlineIssueEmpty, heading.Order, fmt.Sprintf("h%d", heading.Level))
+		}
+		if heading.Hidden {
+			c.issue(models.OutlineIssueHidden, heading.Order, heading.Text)
+			continue
+		}
+		if heading.Level == 1 {
+			h1s++
+		}
+		if previous > 0 && heading.Level > previous+1 {
+			c.issue(models.OutlineIssueSkippedLevel, heading.Order, fmt.Sprintf("h%d to h%d", previous, heading.Level))
+		}
+		previous = heading.Level
+	}
+	if h1s == 0 {
+		c.issue(models.OutlineIssueMissingH1, 0, "")
+	} else if h1s > 1 {
+		c.issue(models.OutlineIssueMultipleH1, 0, fmt.Sprintf("%d h1 headings", h1s))
+	}
+	return nil
+}
+
+func (c *outlineCheck) issue(issue string, order int, detail string) {
+	c.section.Issues = append(c.section.Issues, models.OutlineIssue{Issue: issue, Order: order, Detail: detail})
+}
+
+func (c *outlineCheck) Section() interface{} {
+	return c.section
+}
+
+// level of a h1 to h6 tag, 0 for the other tags
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// elements without an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// tells if the element is hidden by the hidden attribute, aria-hidden, an inline style or being a template
+func isHidden(token html.Token) bool {
+	if token.Data == "template" {
+		return true
+	}
+	for _, a := range token.Attr {
+		switch a.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(strings.TrimSpace(a.Val), "true") {
+				return true
+			}
+		case "style":
+			style := strings.ToLower(strings.ReplaceAll(a.Val, " ", ""))
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
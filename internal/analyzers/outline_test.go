@@ -0,0 +1,84 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Outline(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectHeadings []models.OutlineHeading
+		expectIssues   []models.OutlineIssue
+	}{
+		{
+			name: "Valid outline",
+			body: `<h1>Home <small>page</small></h1>
+				<h2>About   us</h2><h3><img src="a.png" alt="Team"></h3>
+				<h2>Contact</h2>`,
+			expectHeadings: []models.OutlineHeading{
+				{Order: 1, Level: 1, Text: "Home page"},
+				{Order: 2, Level: 2, Text: "About us"},
+				{Order: 3, Level: 3, Text: "Team"},
+				{Order: 4, Level: 2, Text: "Contact"},
+			},
+			expectIssues: []models.OutlineIssue{},
+		},
+		{
+			name: "Missing h1, skipped level and empty heading",
+			body: `<h2>About</h2><h4>Details</h4><h3> </h3>`,
+			expectHeadings: []models.OutlineHeading{
+				{Order: 1, Level: 2, Text: "About"},
+				{Order: 2, Level: 4, Text: "Details"},
+				{Order: 3, Level: 3, Text: ""},
+			},
+			expectIssues: []models.OutlineIssue{
+				{Issue: models.OutlineIssueSkippedLevel, Order: 2, Detail: "h2 to h4"},
+				{Issue: models.OutlineIssueEmpty, Order: 3, Detail: "h3"},
+				{Issue: models.OutlineIssueMissingH1},
+			},
+		},
+		{
+			name: "Multiple h1 and hidden headings",
+			body: `<h1>One</h1>
+				<div style="display: none"><div><p>x</p></div><h1>Hidden</h1></div>
+				<h2 hidden>Also hidden</h2>
+				<section aria-hidden="true"><h4>Aria</h4></section>
+				<h1>Two</h1>`,
+			expectHeadings: []models.OutlineHeading{
+				{Order: 1, Level: 1, Text: "One"},
+				{Order: 2, Level: 1, Text: "Hidden", Hidden: true},
+				{Order: 3, Level: 2, Text: "Also hidden", Hidden: true},
+				{Order: 4, Level: 4, Text: "Aria", Hidden: true},
+				{Order: 5, Level: 1, Text: "Two"},
+			},
+			expectIssues: []models.OutlineIssue{
+				{Issue: models.OutlineIssueHidden, Order: 2, Detail: "Hidden"},
+				{Issue: models.OutlineIssueHidden, Order: 3, Detail: "Also hidden"},
+				{Issue: models.OutlineIssueHidden, Order: 4, Detail: "Aria"},
+				{Issue: models.OutlineIssueMultipleH1, Detail: "2 h1 headings"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := BodyAnalyzer{
+				Fetcher: &fetcher.MockFetcher{ResponseBody: "<html><body>" + tt.body + "</body></html>"},
+				Stream:  make(chan string, 50),
+				Workers: 1,
+				Checks:  []string{"outline"},
+			}
+			assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+			outline := a.Output.Sections["outline"].(models.OutlineSection)
+			assert.Equal(t, tt.expectHeadings, outline.Headings)
+			assert.Equal(t, tt.expectIssues, outline.Issues)
+		})
+	}
+}
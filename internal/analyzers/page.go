@@ -0,0 +1,47 @@
+package analyzers
+
+import (
+	"context"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// runs the full BodyAnalyzer on single pages for the multi page modes (crawl, sitemap)
+// robots is passed on to the link checker of every page
+type pageAnalyzer struct {
+	Fetcher   fetcher.BodyFetcher
+	Scheduler *HostScheduler
+	Robots    *fetcher.RobotsCache
+	Workers   int
+}
+
+// analyzes a single page with its own BodyAnalyzer
+// the per token updates of the page are not forwarded, only the final page result is returned
+// together with the internal links of its a elements, the links to other pages of the site
+func (p pageAnalyzer) analyze(ctx context.Context, url string, depth int) (models.CrawlPage, []string, *models.ErrorOut) {
+	a := BodyAnalyzer{
+		Fetcher:   p.Fetcher,
+		Scheduler: p.Scheduler,
+		Robots:    p.Robots,
+		Stream:    make(chan string, 20),
+		Output:    models.Output{},
+		Workers:   p.Workers,
+	}
+	drained := make(chan struct{})
+	go func() {
+		for range a.Stream {
+		}
+		close(drained)
+	}()
+
+	errObj := a.Analyze(ctx, url)
+	close(a.Stream)
+	<-drained
+
+	page := models.CrawlPage{Url: url, Depth: depth, Output: a.Output}
+	if errObj != nil {
+		page.Error = errObj.Error
+	}
+	return page, a.anchors, errObj
+}
@@ -0,0 +1,185 @@
+package analyzers
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"golang.org/x/net/html"
+)
+
+// category of a preload hint by its as attribute
+var preloadCategories = map[string]string{
+	"script": models.ResourceScript, "style": models.ResourceStylesheet, "font": models.ResourceFont,
+	"image": models.ResourceImage, "audio": models.ResourceMedia, "video": models.ResourceMedia,
+	"track": models.ResourceMedia, "document": models.ResourceIframe, "embed": models.ResourceEmbed,
+	"object": models.ResourceObject,
+}
+
+// extensions of the font files referenced from inline style elements
+var fontExtensions = map[string]bool{".woff2": true, ".woff": true, ".ttf": true, ".otf": true, ".eot": true}
+
+func init() {
+	Register("resources", func(a *BodyAnalyzer) TokenAnalyzer {
+		return &resourcesCheck{a: a, links: newLinkResults(a)}
+	}, false)
+}
+
+// collects the subresources of the page (scripts, stylesheets and preload hints, iframes, media, embeds, objects
+// and the fonts of inline style elements), images are left to the images check
+// keys holds the key of the check result of every resource, in media and in style track the open elements
+type resourcesCheck struct {
+	a       *BodyAnalyzer
+	links   *linkResults
+	section models.ResourcesSection
+	keys    []string
+	inMedia int
+	inStyle bool
+}
+
+func (c *resourcesCheck) Token(tokenType html.TokenType, token html.Token) error {
+	switch tokenType {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		switch token.Data {
+		case "script":
+			return c.add(token, models.ResourceScript, "src", "")
+		case "link":
+			return c.link(token)
+		case "iframe", "frame":
+			return c.add(token, models.ResourceIframe, "src", "")
+		case "video", "audio":
+			if tokenType == html.StartTagToken {
+				c.inMedia++
+			}
+			return c.add(token, models.ResourceMedia, "src", "")
+		case "source":
+			// sources of a picture are images
+			if c.inMedia > 0 {
+				return c.add(token, models.ResourceMedia, "src", "")
+			}
+		case "track":
+			return c.add(token, models.ResourceMedia, "src", "")
+		case "embed":
+			return c.add(token, models.ResourceEmbed, "src", "")
+		case "object":
+			return c.add(token, models.ResourceObject, "data", "")
+		case "style":
+			c.inStyle = tokenType == html.StartTagToken
+		}
+	case html.EndTagToken:
+		switch token.Data {
+		case "video", "audio":
+			if c.inMedia > 0 {
+				c.inMedia--
+			}
+		case "style":
+			c.inStyle = false
+		}
+	case html.TextToken:
+		if c.inStyle {
+			return c.fonts(token.Data)
+		}
+	}
+	return nil
+}
+
+// stylesheets and preload hints, other link elements (icons, canonical, preconnect) are not resources of the page
+func (c *resourcesCheck) link(token html.Token) error {
+	rel := attr(token, "rel")
+	switch {
+	case hasToken(rel, "stylesheet"):
+		return c.add(token, models.ResourceStylesheet, "href", "")
+	case hasToken(rel, "modulepreload"):
+		return c.add(token, models.ResourceScript, "href", "modulepreload")
+	}
+	for _, hint := range []string{"preload", "prefetch"} {
+		if hasToken(rel, hint) {
+			category, ok := preloadCategories[strings.ToLower(attr(token, "as"))]
+			if !ok {
+				category = models.ResourceOther
+			}
+			return c.add(token, category, "href", hint)
+		}
+	}
+	return nil
+}
+
+// font files referenced with url() in an inline style element, other urls are left out
+func (c *resourcesCheck) fonts(css string) error {
+	for _, match := range cssUrlPattern.FindAllStringSubmatch(css, -1) {
+		file, _, _ := strings.Cut(match[1], "?")
+		if !fontExtensions[strings.ToLower(path.Ext(file))] {
+			continue
+		}
+		if err := c.queue(models.Resource{Url: match[1], Category: models.ResourceFont, Tag: "style", Attribute: "url"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adds the resource in the attribute of the element, an element without it is skipped
+func (c *resourcesCheck) add(token html.Token, category, attribute, hint string) error {
+	value := strings.TrimSpace(attr(token, attribute))
+	if value == "" {
+		return nil
+	}
+	return c.queue(models.Resource{Url: value, Category: category, Tag: token.Data, Attribute: attribute, Hint: hint})
+}
+
+// adds a resource with its origin and queues the check of its url
+func (c *resourcesCheck) queue(resource models.Resource) error {
+	resource.Line = c.a.Line()
+	resource.Origin = models.ResourceOriginFirstParty
+	if !utils.IsSameSite(resource.Url, c.a.url) {
+		resource.Origin = models.ResourceOriginThirdParty
+	}
+	key, err := c.links.queue(resource.Url, resource.Tag, resource.Attribute)
+	c.section.Resources = append(c.section.Resources, resource)
+	c.keys = append(c.keys, key)
+	return err
+}
+
+// the totals count every reference, broken counts the distinct urls overall and per category
+func (c *resourcesCheck) Finalize() error {
+	c.section.Total = len(c.section.Resources)
+	c.section.Categories = map[string]models.ResourceCount{}
+	c.section.ThirdPartyHosts = map[string]int{}
+	broken := map[string]bool{}
+	brokenIn := map[string]map[string]bool{}
+	for i := range c.section.Resources {
+		resource := &c.section.Resources[i]
+		count := c.section.Categories[resource.Category]
+		count.Total++
+		if resource.Origin == models.ResourceOriginThirdParty {
+			count.ThirdParty++
+			c.section.ThirdParty++
+			if u, err := url.Parse(resource.Url); err == nil {
+				c.section.ThirdPartyHosts[u.Host]++
+			}
+		}
+		resource.Check = c.links.result(c.keys[i])
+		if resource.Check != nil && resource.Check.State == models.LinkStateInactive {
+			resource.Broken = true
+			broken[c.keys[i]] = true
+			if brokenIn[resource.Category] == nil {
+				brokenIn[resource.Category] = map[string]bool{}
+			}
+			brokenIn[resource.Category][c.keys[i]] = true
+			count.Broken = len(brokenIn[resource.Category])
+		}
+		c.section.Categories[resource.Category] = count
+	}
+	c.section.Broken = len(broken)
+	return nil
+}
+
+func (c *resourcesCheck) Section() interface{} {
+	section := c.section
+	if section.Resources == nil {
+		section.Resources = []models.Resource{}
+	}
+	return section
+}
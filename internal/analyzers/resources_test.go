@@ -0,0 +1,116 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// resources of a page at https://lucytech.se/, the listed urls are available
+func analyzeResources(t *testing.T, body string) models.ResourcesSection {
+	a := BodyAnalyzer{
+		Fetcher: &fetcher.MockFetcher{Pages: map[string]string{
+			"https://lucytech.se/":                "<html><head>" + body + "</head></html>",
+			"https://lucytech.se/app.js":          "",
+			"https://lucytech.se/main.css":        "",
+			"https://cdn.example.com/lib.js":      "",
+			"https://cdn.example.com/font.woff2":  "",
+			"https://www.youtube.com/embed/x":     "",
+			"https://lucytech.se/movie.mp4":       "",
+			"https://lucytech.se/fonts/body.woff": "",
+		}},
+		Stream:  make(chan string, 50),
+		Workers: 2,
+		Checks:  []string{"resources"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+	return a.Output.Sections["resources"].(models.ResourcesSection)
+}
+
+func Test_Resources_Classify(t *testing.T) {
+	first, third := models.ResourceOriginFirstParty, models.ResourceOriginThirdParty
+	tests := []struct {
+		name           string
+		body           string
+		expectUrl      string
+		expectCategory string
+		expectOrigin   string
+		expectHint     string
+	}{
+		{"Script", `<script src="/app.js"></script>`, "/app.js", models.ResourceScript, first, ""},
+		{"Third party script", `<script src="https://cdn.example.com/lib.js"></script>`, "https://cdn.example.com/lib.js", models.ResourceScript, third, ""},
+		{"Look-alike host", `<script src="https://notlucytech.se/lib.js"></script>`, "https://notlucytech.se/lib.js", models.ResourceScript, third, ""},
+		{"Subdomain", `<script src="https://cdn.lucytech.se/lib.js"></script>`, "https://cdn.lucytech.se/lib.js", models.ResourceScript, first, ""},
+		{"Stylesheet", `<link rel="stylesheet" href="/main.css">`, "/main.css", models.ResourceStylesheet, first, ""},
+		{"Module preload", `<link rel="modulepreload" href="/app.js">`, "/app.js", models.ResourceScript, first, "modulepreload"},
+		{"Font preload", `<link rel="preload" as="font" href="https://cdn.example.com/font.woff2">`, "https://cdn.example.com/font.woff2", models.ResourceFont, third, "preload"},
+		{"Image prefetch", `<link rel="prefetch" as="image" href="/hero.png">`, "/hero.png", models.ResourceImage, first, "prefetch"},
+		{"Preload without a known type", `<link rel="preload" as="fetch" href="/data.json">`, "/data.json", models.ResourceOther, first, "preload"},
+		{"Inline style font", `<style>@font-face { src: url("/fonts/body.woff"); } body { background: url(/bg.png); }</style>`, "/fonts/body.woff", models.ResourceFont, first, ""},
+		{"Iframe", `<iframe src="https://www.youtube.com/embed/x"></iframe>`, "https://www.youtube.com/embed/x", models.ResourceIframe, third, ""},
+		{"Video source", `<video><source src="/movie.mp4"></video>`, "/movie.mp4", models.ResourceMedia, first, ""},
+		{"Audio track", `<audio><track src="/captions.vtt"></audio>`, "/captions.vtt", models.ResourceMedia, first, ""},
+		{"Embed", `<embed src="/app.swf">`, "/app.swf", models.ResourceEmbed, first, ""},
+		{"Object", `<object data="/app.pdf"></object>`, "/app.pdf", models.ResourceObject, first, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section := analyzeResources(t, tt.body)
+			if assert.Len(t, section.Resources, 1) {
+				resource := section.Resources[0]
+				assert.Equal(t, tt.expectUrl, resource.Url)
+				assert.Equal(t, tt.expectCategory, resource.Category)
+				assert.Equal(t, tt.expectOrigin, resource.Origin)
+				assert.Equal(t, tt.expectHint, resource.Hint)
+			}
+		})
+	}
+}
+
+func Test_Resources_Skipped(t *testing.T) {
+	section := analyzeResources(t, `
+		<script>var inline = true;</script>
+		<link rel="icon" href="/favicon.ico">
+		<link rel="canonical" href="/">
+		<link rel="preconnect" href="https://cdn.example.com">
+		<picture><source srcset="/hero.webp"></picture>
+		<img src="/hero.png">`)
+	assert.Empty(t, section.Resources)
+	assert.Equal(t, 0, section.Total)
+}
+
+func Test_Resources_Counts(t *testing.T) {
+	section := analyzeResources(t, `
+		<script src="https://cdn.example.com/lib.js"></script>
+		<script src="/app.js"></script>
+		<script src="/gone.js"></script>
+		<script src="/gone.js"></script>
+		<iframe src="https://www.youtube.com/embed/x"></iframe>
+		<iframe src="about:blank"></iframe>
+		<embed src="/gone.js">`)
+
+	assert.Equal(t, 7, section.Total)
+	assert.Equal(t, 2, section.ThirdParty)
+	assert.Equal(t, 1, section.Broken)
+	assert.Equal(t, map[string]models.ResourceCount{
+		models.ResourceScript: {Total: 4, ThirdParty: 1, Broken: 1},
+		models.ResourceIframe: {Total: 2, ThirdParty: 1},
+		models.ResourceEmbed:  {Total: 1, Broken: 1},
+	}, section.Categories)
+	assert.Equal(t, map[string]int{"cdn.example.com": 1, "www.youtube.com": 1}, section.ThirdPartyHosts)
+
+	var broken []int
+	for i, resource := range section.Resources {
+		if resource.Broken {
+			broken = append(broken, resource.Line)
+		}
+		if resource.Url == "about:blank" {
+			assert.Nil(t, section.Resources[i].Check)
+		}
+	}
+	assert.Equal(t, []int{4, 5, 8}, broken)
+}
@@ -0,0 +1,151 @@
+package analyzers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// host aware scheduler sitting between FindLinks and the link check workers
+// the fetcher acquires the host before every request of a link check (retries and the GET fallback included),
+// the workers pass the scheduler with fetcher.WithLimiter, which enforces
+// the max concurrent requests per host, the min delay between requests to the same host
+// and a global requests per second cap across all hosts
+// a single scheduler is meant to be shared by all the analyses so the limits hold for the whole service
+type HostScheduler struct {
+	maxPerHost     int
+	hostDelay      time.Duration
+	globalInterval time.Duration
+	mu             sync.Mutex
+	hosts          map[string]*hostState
+	nextGlobal     time.Time
+}
+
+// slots limits the in flight requests of a host, next is the earliest time the host can be requested again
+type hostState struct {
+	slots chan struct{}
+	next  time.Time
+}
+
+// idle hosts are pruned once this many hosts are tracked
+const maxTrackedHosts = 1024
+
+func NewHostScheduler(config models.SchedulerConfig) *HostScheduler {
+	s := &HostScheduler{
+		maxPerHost: config.MaxPerHost,
+		hostDelay:  config.HostDelay,
+		hosts:      make(map[string]*hostState),
+	}
+	if config.GlobalQPS > 0 {
+		s.globalInterval = time.Duration(float64(time.Second) / config.GlobalQPS)
+	}
+	return s
+}
+
+// blocks until a request to the host of the link is allowed or ctx is done
+// the returned func releases the host and must be called once the request is done
+// a nil scheduler does not limit anything
+func (s *HostScheduler) Acquire(ctx context.Context, link string) (func(), error) {
+	return s.acquire(ctx, link, 0)
+}
+
+// limiter of the scheduler that also waits delay between the requests to a host, the larger delay applies
+// used for the crawl-delay of robots.txt
+func (s *HostScheduler) WithDelay(delay time.Duration) fetcher.Limiter {
+	return delayedLimiter{s: s, delay: delay}
+}
+
+type delayedLimiter struct {
+	s     *HostScheduler
+	delay time.Duration
+}
+
+func (l delayedLimiter) Acquire(ctx context.Context, link string) (func(), error) {
+	return l.s.acquire(ctx, link, l.delay)
+}
+
+func (s *HostScheduler) acquire(ctx context.Context, link string, delay time.Duration) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	host := hostOf(link)
+
+	s.mu.Lock()
+	state := s.hostState(host)
+	s.mu.Unlock()
+
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if state.slots != nil {
+			<-state.slots
+		}
+	}
+
+	// reserve the next free start time for both the host and the global limits
+	s.mu.Lock()
+	now := time.Now()
+	start := now
+	if state.next.After(start) {
+		start = state.next
+	}
+	if s.nextGlobal.After(start) {
+		start = s.nextGlobal
+	}
+	state.next = start.Add(max(s.hostDelay, delay))
+	s.nextGlobal = start.Add(s.globalInterval)
+	s.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// returns the state of the host, creating it when needed
+// must be called while holding mu
+func (s *HostScheduler) hostState(host string) *hostState {
+	if state, ok := s.hosts[host]; ok {
+		return state
+	}
+	if len(s.hosts) >= maxTrackedHosts {
+		now := time.Now()
+		for h, state := range s.hosts {
+			if len(state.slots) == 0 && state.next.Before(now) {
+				delete(s.hosts, h)
+			}
+		}
+	}
+	state := &hostState{}
+	if s.maxPerHost > 0 {
+		state.slots = make(chan struct{}, s.maxPerHost)
+	}
+	s.hosts[host] = state
+	return state
+}
+
+func hostOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
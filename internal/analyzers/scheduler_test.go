@@ -0,0 +1,137 @@
+package analyzers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HostScheduler_MaxPerHost(t *testing.T) {
+	s := NewHostScheduler(models.SchedulerConfig{MaxPerHost: 2})
+
+	var inFlight, maxInFlight int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.Acquire(context.Background(), "https://lucytech.se/page")
+			assert.NoError(t, err)
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxInFlight)
+}
+
+func Test_HostScheduler_Delays(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   models.SchedulerConfig
+		delay    time.Duration
+		links    []string
+		minTotal time.Duration
+		maxTotal time.Duration
+	}{
+		{
+			name:     "Min delay per host",
+			config:   models.SchedulerConfig{HostDelay: 30 * time.Millisecond},
+			links:    []string{"https://lucytech.se/a", "https://lucytech.se/b", "https://lucytech.se/c"},
+			minTotal: 60 * time.Millisecond,
+			maxTotal: time.Second,
+		},
+		{
+			name:     "Different hosts are not delayed",
+			config:   models.SchedulerConfig{HostDelay: time.Second},
+			links:    []string{"https://lucytech.se/", "https://www.home24.de/", "https://example.com/"},
+			maxTotal: 100 * time.Millisecond,
+		},
+		{
+			name:     "Crawl delay longer than the host delay",
+			config:   models.SchedulerConfig{HostDelay: 5 * time.Millisecond},
+			delay:    30 * time.Millisecond,
+			links:    []string{"https://lucytech.se/a", "https://lucytech.se/b", "https://lucytech.se/c"},
+			minTotal: 60 * time.Millisecond,
+			maxTotal: time.Second,
+		},
+		{
+			name:     "Host delay longer than the crawl delay",
+			config:   models.SchedulerConfig{HostDelay: 30 * time.Millisecond},
+			delay:    5 * time.Millisecond,
+			links:    []string{"https://lucytech.se/a", "https://lucytech.se/b", "https://lucytech.se/c"},
+			minTotal: 60 * time.Millisecond,
+			maxTotal: time.Second,
+		},
+		{
+			name:     "Global QPS cap",
+			config:   models.SchedulerConfig{GlobalQPS: 50},
+			links:    []string{"https://lucytech.se/", "https://www.home24.de/", "https://example.com/"},
+			minTotal: 40 * time.Millisecond,
+			maxTotal: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHostScheduler(tt.config)
+			start := time.Now()
+			limiter := s.WithDelay(tt.delay)
+			for _, link := range tt.links {
+				release, err := limiter.Acquire(context.Background(), link)
+				assert.NoError(t, err)
+				release()
+			}
+			total := time.Since(start)
+			assert.GreaterOrEqual(t, total, tt.minTotal)
+			assert.Less(t, total, tt.maxTotal)
+		})
+	}
+}
+
+func Test_HostScheduler_Nil(t *testing.T) {
+	var s *HostScheduler
+	assert.NotPanics(t, func() {
+		release, err := s.Acquire(context.Background(), "https://lucytech.se/")
+		assert.NoError(t, err)
+		release()
+	})
+}
+
+func Test_HostScheduler_Cancel(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.SchedulerConfig
+	}{
+		{name: "Waiting for a slot", config: models.SchedulerConfig{MaxPerHost: 1}},
+		{name: "Waiting for the host delay", config: models.SchedulerConfig{HostDelay: time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHostScheduler(tt.config)
+			release, err := s.Acquire(context.Background(), "https://lucytech.se/")
+			assert.NoError(t, err)
+			defer release()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err = s.Acquire(ctx, "https://lucytech.se/")
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+}
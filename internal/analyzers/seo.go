@@ -0,0 +1,217 @@
+package analyzers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"golang.org/x/net/html"
+)
+
+// search engines and link previews cut longer titles and descriptions
+const (
+	maxTitleLength       = 60
+	maxDescriptionLength = 160
+)
+
+// open graph and twitter card tags held to the title and description lengths, in the order they are reported
+var seoTagLengths = []struct {
+	name string
+	max  int
+}{
+	{"og:title", maxTitleLength},
+	{"og:description", maxDescriptionLength},
+	{"twitter:title", maxTitleLength},
+	{"twitter:description", maxDescriptionLength},
+}
+
+// tags a shared page is expected to have, the card tags fall back to the open graph ones so only the card type is required
+var (
+	requiredOpenGraph   = []string{"og:title", "og:description", "og:image"}
+	requiredTwitterCard = []string{"twitter:card"}
+)
+
+// open graph and twitter card tags that can be repeated (multiple images of a page)
+var repeatableSeoTags = map[string]bool{"og:image": true, "og:image:alt": true, "og:locale:alternate": true}
+
+func init() {
+	Register("seo", func(a *BodyAnalyzer) TokenAnalyzer { return &seoCheck{a: a, counts: map[string]int{}} }, true)
+}
+
+// collects the seo metadata of the page (meta description and robots, canonical link, open graph and twitter card tags)
+// the canonical url is checked by the link checker once it is found
+// counts keeps how often every field is set so duplicates can be reported
+type seoCheck struct {
+	a       *BodyAnalyzer
+	section models.SeoSection
+	counts  map[string]int
+	mu      sync.Mutex
+}
+
+func (c *seoCheck) Token(tokenType html.TokenType, token html.Token) error {
+	if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+		return nil
+	}
+	switch token.Data {
+	case "meta":
+		c.meta(token)
+	case "link":
+		return c.link(token)
+	}
+	return nil
+}
+
+func (c *seoCheck) meta(token html.Token) {
+	name := strings.ToLower(firstAttr(token, "name", "property"))
+	content := strings.TrimSpace(attr(token, "content"))
+	switch {
+	case name == "description":
+		c.set("description", content, &c.section.Description)
+	case name == "robots":
+		c.set("robots", content, &c.section.Robots)
+	case strings.HasPrefix(name, "og:"):
+		c.setTag(&c.section.OpenGraph, name, content)
+	case strings.HasPrefix(name, "twitter:"):
+		c.setTag(&c.section.TwitterCard, name, content)
+	}
+}
+
+func (c *seoCheck) link(token html.Token) error {
+	if !hasToken(attr(token, "rel"), "canonical") {
+		return nil
+	}
+	href := strings.TrimSpace(attr(token, "href"))
+	c.set("canonical", href, &c.section.Canonical)
+	if c.counts["canonical"] > 1 || href == "" {
+		return nil
+	}
+	return c.a.QueueLink(models.LinkJob{Url: href, Tag: "link", Attribute: "href", Done: func(result models.LinkResult) {
+		c.mu.Lock()
+		c.section.CanonicalCheck = &result
+		c.mu.Unlock()
+	}})
+}
+
+// keeps the first value of a field, later ones are only counted
+func (c *seoCheck) set(field, value string, target *string) {
+	c.counts[field]++
+	if c.counts[field] == 1 {
+		*target = value
+	}
+}
+
+func (c *seoCheck) setTag(tags *map[string]string, name, value string) {
+	if *tags == nil {
+		*tags = map[string]string{}
+	}
+	c.counts[name]++
+	if _, ok := (*tags)[name]; !ok {
+		(*tags)[name] = value
+	}
+}
+
+// runs after the link checks, so the canonical check result is complete
+func (c *seoCheck) Finalize() error {
+	s := &c.section
+	c.require("description", s.Description)
+	c.maxLength("description", s.Description, maxDescriptionLength)
+	if hasToken(strings.ReplaceAll(s.Robots, ",", " "), "noindex") {
+		c.issue("robots", models.SeoIssueNoIndex, s.Robots)
+	}
+
+	c.require("canonical", s.Canonical)
+	if check := s.CanonicalCheck; check != nil {
+		if check.State != models.LinkStateActive {
+			c.issue("canonical", models.SeoIssueBroken, check.Error)
+		}
+		s.CanonicalSelf = utils.NormalizeUrl(check.ResolvedUrl) == utils.NormalizeUrl(c.a.url)
+		if !s.CanonicalSelf {
+			c.issue("canonical", models.SeoIssueNotSelf, check.ResolvedUrl)
+		}
+	}
+
+	for _, name := range requiredOpenGraph {
+		c.require(name, s.OpenGraph[name])
+	}
+	for _, name := range requiredTwitterCard {
+		c.require(name, s.TwitterCard[name])
+	}
+	for _, tag := range seoTagLengths {
+		tags := s.OpenGraph
+		if strings.HasPrefix(tag.name, "twitter:") {
+			tags = s.TwitterCard
+		}
+		c.maxLength(tag.name, tags[tag.name], tag.max)
+	}
+
+	// fields are sorted so the duplicate issues come in a stable order
+	fields := make([]string, 0, len(c.counts))
+	for field := range c.counts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if c.counts[field] > 1 && !repeatableSeoTags[field] {
+			c.issue(field, models.SeoIssueDuplicate, fmt.Sprintf("set %d times", c.counts[field]))
+		}
+	}
+	return nil
+}
+
+// reports a missing field, or an empty one when the tag is there without a value
+func (c *seoCheck) require(field, value string) {
+	if c.counts[field] == 0 {
+		c.issue(field, models.SeoIssueMissing, "")
+	} else if value == "" {
+		c.issue(field, models.SeoIssueEmpty, "")
+	}
+}
+
+// reports a value longer than max characters
+func (c *seoCheck) maxLength(field, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		c.issue(field, models.SeoIssueTooLong, fmt.Sprintf("%d characters, max %d", n, max))
+	}
+}
+
+func (c *seoCheck) issue(field, issue, detail string) {
+	c.section.Issues = append(c.section.Issues, models.SeoIssue{Field: field, Issue: issue, Detail: detail})
+}
+
+func (c *seoCheck) Section() interface{} {
+	return c.section
+}
+
+// value of an attribute of the token, empty when it is not set
+func attr(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// value of the first of the attributes that is set
+func firstAttr(token html.Token, keys ...string) string {
+	for _, key := range keys {
+		if val := attr(token, key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// tells if a space separated attribute value (rel, robots content) contains the token, case insensitive
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
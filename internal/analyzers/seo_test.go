@@ -0,0 +1,141 @@
+package analyzers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Seo(t *testing.T) {
+	complete := `
+		<meta name="description" content="A page about testing">
+		<meta name="robots" content="index, follow">
+		<link rel="canonical" href="/blog/">
+		<meta property="og:title" content="Testing">
+		<meta property="og:description" content="A page about testing">
+		<meta property="og:image" content="https://lucytech.se/a.png">
+		<meta property="og:image" content="https://lucytech.se/b.png">
+		<meta name="twitter:card" content="summary_large_image">`
+
+	tests := []struct {
+		name         string
+		head         string
+		expectSelf   bool
+		expectIssues []models.SeoIssue
+	}{
+		{
+			name:       "Complete metadata",
+			head:       complete,
+			expectSelf: true,
+		},
+		{
+			name: "Nothing set",
+			head: "",
+			expectIssues: []models.SeoIssue{
+				{Field: "description", Issue: models.SeoIssueMissing},
+				{Field: "canonical", Issue: models.SeoIssueMissing},
+				{Field: "og:title", Issue: models.SeoIssueMissing},
+				{Field: "og:description", Issue: models.SeoIssueMissing},
+				{Field: "og:image", Issue: models.SeoIssueMissing},
+				{Field: "twitter:card", Issue: models.SeoIssueMissing},
+			},
+		},
+		{
+			name: "Duplicate, empty and too long values",
+			head: `
+				<meta name="description" content="` + strings.Repeat("a", 161) + `">
+				<meta property="og:title" content="">` + complete + `
+				<meta name="robots" content="noindex,nofollow">`,
+			expectSelf: true,
+			expectIssues: []models.SeoIssue{
+				{Field: "description", Issue: models.SeoIssueTooLong, Detail: "161 characters, max 160"},
+				{Field: "og:title", Issue: models.SeoIssueEmpty},
+				{Field: "description", Issue: models.SeoIssueDuplicate, Detail: "set 2 times"},
+				{Field: "og:title", Issue: models.SeoIssueDuplicate, Detail: "set 2 times"},
+				{Field: "robots", Issue: models.SeoIssueDuplicate, Detail: "set 2 times"},
+			},
+		},
+		{
+			name: "Open graph and twitter card values at the limits",
+			head: strings.Replace(complete, `content="A page about testing">
+		<meta property="og:image"`, `content="`+strings.Repeat("d", 160)+`">
+		<meta property="og:image"`, 1) + `
+				<meta name="twitter:title" content="` + strings.Repeat("t", 60) + `">`,
+			expectSelf: true,
+		},
+		{
+			name: "Too long open graph and twitter card values",
+			head: strings.Replace(complete, `content="Testing"`, `content="`+strings.Repeat("é", 61)+`"`, 1) + `
+				<meta name="twitter:title" content="` + strings.Repeat("t", 61) + `">
+				<meta name="twitter:description" content="` + strings.Repeat("d", 161) + `">`,
+			expectSelf: true,
+			expectIssues: []models.SeoIssue{
+				{Field: "og:title", Issue: models.SeoIssueTooLong, Detail: "61 characters, max 60"},
+				{Field: "twitter:title", Issue: models.SeoIssueTooLong, Detail: "61 characters, max 60"},
+				{Field: "twitter:description", Issue: models.SeoIssueTooLong, Detail: "161 characters, max 160"},
+			},
+		},
+		{
+			name: "Canonical to another page that does not resolve",
+			head: strings.Replace(complete, `href="/blog/"`, `href="https://lucytech.se/missing"`, 1),
+			expectIssues: []models.SeoIssue{
+				{Field: "canonical", Issue: models.SeoIssueBroken, Detail: "mock err"},
+				{Field: "canonical", Issue: models.SeoIssueNotSelf, Detail: "https://lucytech.se/missing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := "<html><head>" + tt.head + "</head><body></body></html>"
+			a := BodyAnalyzer{
+				Fetcher: &fetcher.MockFetcher{Pages: map[string]string{"https://lucytech.se/blog": page, "https://lucytech.se/blog/": page}},
+				Stream:  make(chan string, 50),
+				Workers: 2,
+				Checks:  []string{"seo"},
+			}
+			assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/blog"))
+
+			seo := a.Output.Sections["seo"].(models.SeoSection)
+			assert.Equal(t, tt.expectSelf, seo.CanonicalSelf)
+			assert.Equal(t, tt.expectIssues, seo.Issues)
+			// seo links are not page links
+			assert.Empty(t, a.Output.LinkResults)
+		})
+	}
+}
+
+func Test_Seo_Values(t *testing.T) {
+	page := `<html><head>
+		<meta name="Description" content=" Testing ">
+		<meta name="robots" content="noindex">
+		<link rel="icon canonical" href="https://lucytech.se/">
+		<meta property="og:title" content="Testing">
+		<meta property="og:image" content="https://lucytech.se/a.png">
+		<meta name="twitter:card" content="summary">
+		<meta name="twitter:site" content="@lucytech">
+		</head></html>`
+	a := BodyAnalyzer{
+		Fetcher: &fetcher.MockFetcher{Pages: map[string]string{"https://lucytech.se/": page}},
+		Stream:  make(chan string, 50),
+		Workers: 1,
+		Checks:  []string{"seo"},
+	}
+	assert.Nil(t, a.Analyze(context.Background(), "https://lucytech.se/"))
+
+	seo := a.Output.Sections["seo"].(models.SeoSection)
+	assert.Equal(t, "Testing", seo.Description)
+	assert.Equal(t, "noindex", seo.Robots)
+	assert.Equal(t, "https://lucytech.se/", seo.Canonical)
+	assert.Equal(t, models.LinkStateActive, seo.CanonicalCheck.State)
+	assert.Equal(t, map[string]string{"og:title": "Testing", "og:image": "https://lucytech.se/a.png"}, seo.OpenGraph)
+	assert.Equal(t, map[string]string{"twitter:card": "summary", "twitter:site": "@lucytech"}, seo.TwitterCard)
+	assert.Equal(t, []models.SeoIssue{
+		{Field: "robots", Issue: models.SeoIssueNoIndex, Detail: "noindex"},
+		{Field: "og:description", Issue: models.SeoIssueMissing},
+	}, seo.Issues)
+}
@@ -0,0 +1,145 @@
+package analyzers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+)
+
+// sitemap analyzer configuration fields
+// sitemaps are discovered from the Sitemap lines of robots.txt and /sitemap.xml, sitemap indexes are followed
+// maxUrls is the page budget and maxSitemaps caps the number of sitemap files fetched
+// stream receives the result of every analyzed page together with the sitemap summary
+// robots is respected for the listed pages, robotsLinks makes the link checker of every page respect it too
+type SitemapAnalyzer struct {
+	Fetcher     fetcher.BodyFetcher
+	Scheduler   *HostScheduler
+	Robots      *fetcher.RobotsCache
+	RobotsLinks bool
+	Stream      chan string
+	Output      models.SitemapOutput
+	Workers     int
+	MaxUrls     int
+	MaxSitemaps int
+}
+
+// runs the full BodyAnalyzer on every url listed in the sitemaps of the site
+// reports listed urls that could not be fetched and the internal a links of the pages that are missing from the sitemaps
+// stops with an error once ctx is done, the pages analyzed so far are already streamed
+func (s *SitemapAnalyzer) Analyze(ctx context.Context, siteUrl string) *models.ErrorOut {
+	pageUrls := s.discover(ctx, siteUrl)
+	if ctx.Err() != nil {
+		return contextErrOut(ctx.Err())
+	}
+	if len(pageUrls) == 0 {
+		return &models.ErrorOut{StatusCode: http.StatusNotFound, Code: models.ErrCodeNoSitemap, Error: "no sitemap found"}
+	}
+
+	listed := map[string]bool{}
+	for _, pageUrl := range pageUrls {
+		listed[utils.NormalizeUrl(pageUrl)] = true
+	}
+	missing := map[string]bool{}
+	pages := pageAnalyzer{Fetcher: s.Fetcher, Scheduler: s.Scheduler, Workers: s.Workers}
+	if s.RobotsLinks {
+		pages.Robots = s.Robots
+	}
+
+	summary := &s.Output.Summary
+	for _, pageUrl := range pageUrls {
+		if !s.Robots.Allowed(pageUrl) {
+			summary.PagesBlocked = append(summary.PagesBlocked, pageUrl)
+			continue
+		}
+
+		page, anchors, errObj := pages.analyze(ctx, pageUrl, 0)
+		if ctx.Err() != nil {
+			return contextErrOut(ctx.Err())
+		}
+		summary.PagesAnalyzed++
+		if errObj != nil {
+			summary.FailedUrls = append(summary.FailedUrls, models.SitemapFailure{Url: pageUrl, Error: errObj.Error})
+		}
+		for _, link := range anchors {
+			resolved := utils.ResolveUrl(link, pageUrl)
+			if resolved == "" || !utils.IsSameSite(resolved, pageUrl) {
+				continue
+			}
+			key := utils.NormalizeUrl(resolved)
+			if listed[key] || missing[key] {
+				continue
+			}
+			missing[key] = true
+			summary.MissingFromSitemap = append(summary.MissingFromSitemap, resolved)
+		}
+
+		s.Output.Page = &page
+		jsonStr, err := utils.JsonToText(s.Output)
+		if err != nil {
+			return &models.ErrorOut{StatusCode: http.StatusInternalServerError, Code: models.ErrCodeInternal, Error: err.Error()}
+		}
+		select {
+		case s.Stream <- *jsonStr:
+		case <-ctx.Done():
+			return contextErrOut(ctx.Err())
+		}
+	}
+	return nil
+}
+
+// finds the page urls listed in the sitemaps of the site, up to maxUrls
+// sitemaps that can not be fetched or parsed are skipped
+func (s *SitemapAnalyzer) discover(ctx context.Context, siteUrl string) []string {
+	u, err := url.Parse(siteUrl)
+	if err != nil {
+		return nil
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	var queue []string
+	if s.Robots != nil {
+		queue = append(queue, s.Robots.Rules(siteUrl).Sitemaps...)
+	}
+	queue = append(queue, origin+"/sitemap.xml")
+
+	seenSitemaps := map[string]bool{}
+	seenUrls := map[string]bool{}
+	var pageUrls []string
+	fetched := 0
+	for ctx.Err() == nil && len(queue) > 0 && fetched < s.MaxSitemaps && len(pageUrls) < s.MaxUrls {
+		sitemapUrl := queue[0]
+		queue = queue[1:]
+		if seenSitemaps[sitemapUrl] {
+			continue
+		}
+		seenSitemaps[sitemapUrl] = true
+		fetched++
+
+		body, err := s.Fetcher.FetchBody(ctx, sitemapUrl)
+		if err != nil {
+			continue
+		}
+		urls, nested, err := fetcher.ParseSitemap(body)
+		body.Close()
+		if err != nil {
+			continue
+		}
+		s.Output.Summary.Sitemaps = append(s.Output.Summary.Sitemaps, sitemapUrl)
+		queue = append(queue, nested...)
+
+		for _, pageUrl := range urls {
+			key := utils.NormalizeUrl(pageUrl)
+			if seenUrls[key] || len(pageUrls) >= s.MaxUrls {
+				continue
+			}
+			seenUrls[key] = true
+			pageUrls = append(pageUrls, pageUrl)
+		}
+	}
+	s.Output.Summary.UrlsListed = len(pageUrls)
+	return pageUrls
+}
@@ -0,0 +1,126 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SitemapAnalyzer(t *testing.T) {
+	pages := map[string]string{
+		"https://lucytech.se/robots.txt": "User-agent: *\nDisallow: /private\nSitemap: https://lucytech.se/sitemap-index.xml",
+		"https://lucytech.se/sitemap-index.xml": `<sitemapindex>
+			<sitemap><loc>https://lucytech.se/sitemap-pages.xml</loc></sitemap>
+		</sitemapindex>`,
+		"https://lucytech.se/sitemap-pages.xml": `<urlset>
+			<url><loc>https://lucytech.se/</loc></url>
+			<url><loc>https://lucytech.se/contact</loc></url>
+			<url><loc>https://lucytech.se/private/page</loc></url>
+			<url><loc>https://lucytech.se/gone</loc></url>
+		</urlset>`,
+	}
+	for url, body := range crawlSite {
+		pages[url] = body
+	}
+	mock := &fetcher.MockFetcher{Pages: pages}
+
+	tests := []struct {
+		name          string
+		maxUrls       int
+		expectPages   []string
+		expectSummary models.SitemapSummary
+	}{
+		{
+			name:        "Analyzes listed urls",
+			maxUrls:     10,
+			expectPages: []string{"https://lucytech.se/", "https://lucytech.se/contact", "https://lucytech.se/gone"},
+			// the stylesheet of the start page is not a page missing from the sitemap
+			expectSummary: models.SitemapSummary{
+				Sitemaps:           []string{"https://lucytech.se/sitemap-index.xml", "https://lucytech.se/sitemap-pages.xml"},
+				UrlsListed:         4,
+				PagesAnalyzed:      3,
+				FailedUrls:         []models.SitemapFailure{{Url: "https://lucytech.se/gone", Error: "404 is returned"}},
+				MissingFromSitemap: []string{"https://lucytech.se/about", "https://lucytech.se/missing"},
+				PagesBlocked:       []string{"https://lucytech.se/private/page"},
+			},
+		},
+		{
+			name:        "Stops at the url limit",
+			maxUrls:     1,
+			expectPages: []string{"https://lucytech.se/"},
+			expectSummary: models.SitemapSummary{
+				Sitemaps:           []string{"https://lucytech.se/sitemap-index.xml", "https://lucytech.se/sitemap-pages.xml"},
+				UrlsListed:         1,
+				PagesAnalyzed:      1,
+				MissingFromSitemap: []string{"https://lucytech.se/about", "https://lucytech.se/contact", "https://lucytech.se/missing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SitemapAnalyzer{
+				Fetcher:     mock,
+				Robots:      fetcher.NewRobotsCache(mock, "web-analyzer", time.Hour),
+				Stream:      make(chan string, 20),
+				Workers:     2,
+				MaxUrls:     tt.maxUrls,
+				MaxSitemaps: 5,
+			}
+
+			errObj := s.Analyze(context.Background(), "https://lucytech.se/")
+			assert.Nil(t, errObj)
+			close(s.Stream)
+
+			var pages []string
+			var last models.SitemapOutput
+			for msg := range s.Stream {
+				last = models.SitemapOutput{}
+				assert.NoError(t, json.Unmarshal([]byte(msg), &last))
+				pages = append(pages, last.Page.Url)
+			}
+			assert.Equal(t, tt.expectPages, pages)
+			assert.Equal(t, tt.expectSummary, last.Summary)
+		})
+	}
+}
+
+func Test_SitemapAnalyzer_Hosts(t *testing.T) {
+	mock := &fetcher.MockFetcher{Pages: map[string]string{
+		"https://example.com/sitemap.xml": `<urlset><url><loc>https://example.com/</loc></url></urlset>`,
+		"https://example.com/": `<html><body>
+			<a href="https://notexample.com/x">Look-alike</a>
+			<a href="https://example.com.evil.net/">Suffix</a>
+			<a href="https://blog.example.com/">Blog</a>
+		</body></html>`,
+	}}
+	s := SitemapAnalyzer{
+		Fetcher:     mock,
+		Stream:      make(chan string, 20),
+		Workers:     2,
+		MaxUrls:     10,
+		MaxSitemaps: 5,
+	}
+
+	assert.Nil(t, s.Analyze(context.Background(), "https://example.com/"))
+	assert.Equal(t, []string{"https://blog.example.com/"}, s.Output.Summary.MissingFromSitemap)
+}
+
+func Test_SitemapAnalyzer_NotFound(t *testing.T) {
+	s := SitemapAnalyzer{
+		Fetcher:     &fetcher.MockFetcher{Pages: crawlSite},
+		Stream:      make(chan string, 20),
+		Workers:     2,
+		MaxUrls:     10,
+		MaxSitemaps: 5,
+	}
+
+	errObj := s.Analyze(context.Background(), "https://lucytech.se/")
+	assert.NotNil(t, errObj)
+	assert.Equal(t, 404, errObj.StatusCode)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// max size of an uploaded html page
+const maxHtmlBody = 10 << 20
+
+// Gin Api handler used to analyze raw html sent in the request body instead of a fetched page
+// the optional base_url query param is the url the page will be published at, its relative links are resolved against it
+// without it only the absolute links can be checked
+// responds like the result api, a text/event-stream or a single json result when asked for json (see wantsJSON)
+// uploads are not cached
+func PostAnalyzeHtmlHandler(c *gin.Context) {
+	pageUrl := fetcher.RawPageUrl
+	if baseUrl := c.Query("base_url"); baseUrl != "" {
+		if errObj := utils.UrlValidationCheck(&baseUrl); errObj != nil {
+			c.JSON(errObj.StatusCode, errObj)
+			return
+		}
+		pageUrl = baseUrl
+	}
+	html, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxHtmlBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid request body"})
+		return
+	}
+	if len(html) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "empty body"})
+		return
+	}
+
+	checks, errObj := parseChecks(c.Query("checks"))
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	a := newBodyAnalyzer(checks)
+	a.Fetcher = &fetcher.ReaderFetcher{Url: pageUrl, Html: html, Remote: getFetcher()}
+
+	if wantsJSON(c) {
+		timeout, errObj := queryTimeout(c, configs.GetAnalyzeConfig().Timeout)
+		if errObj != nil {
+			c.JSON(errObj.StatusCode, errObj)
+			return
+		}
+		ctx, cancel := withTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		result, errObj := analyzeToResult(ctx, a, pageUrl)
+		if errObj != nil {
+			c.JSON(errObj.StatusCode, errObj)
+			return
+		}
+		writeResultData(c, result)
+		return
+	}
+
+	startStream(c)
+	streamResults(c, a.Stream, configs.GetAnalyzeConfig().Timeout, func(ctx context.Context) *models.ErrorOut {
+		return a.Analyze(ctx, pageUrl)
+	}, nil)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// max size of a batch request body
+const maxBatchBody = 1 << 20
+
+// Gin Api handler used to analyze a list of urls
+// expects a json array of urls or one url per line, capped by the batch configs
+// streams a url_result event per url as ndjson (default) or as a text/event-stream when asked for
+// ends with the summary of the batch or the error that stopped it
+func PostBatchHandler(c *gin.Context) {
+	batchConfig := configs.GetBatchConfig()
+	urls, errObj := readBatchUrls(c, batchConfig.MaxUrls)
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+
+	batch := analyzers.BatchAnalyzer{
+		Fetcher:   getFetcher(),
+		Scheduler: getScheduler(),
+		Robots:    getLinkRobots(),
+		Stream:    make(chan string, 20),
+		Workers:   runtime.NumCPU(),
+		Slots:     getBatchSlots(),
+	}
+	run := func(ctx context.Context) *models.ErrorOut {
+		return batch.Analyze(ctx, urls)
+	}
+	summary := func() interface{} {
+		return batch.Output
+	}
+	if wantsSSE(c) {
+		startStream(c)
+		streamResults(c, batch.Stream, batchConfig.Timeout, run, summary)
+		return
+	}
+	streamNDJSON(c, batch.Stream, batchConfig.Timeout, run, summary)
+}
+
+// reads the urls of a batch request, a body starting with [ is a json array, otherwise one url per line
+// blank lines are skipped
+func readBatchUrls(c *gin.Context, max int) ([]string, *models.ErrorOut) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBody))
+	if err != nil {
+		return nil, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid request body"}
+	}
+
+	var urls []string
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		if err := json.Unmarshal(body, &urls); err != nil {
+			return nil, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid request body"}
+		}
+	} else {
+		for _, line := range strings.Split(string(body), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				urls = append(urls, line)
+			}
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "no urls"}
+	}
+	if len(urls) > max {
+		return nil, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: fmt.Sprintf("too many urls, max %d", max)}
+	}
+	return urls, nil
+}
+
+// format=sse or an Accept header asking for text/event-stream
+func wantsSSE(c *gin.Context) bool {
+	if c.Query("format") == "sse" {
+		return true
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+// same as streamResults but writes every message as a line of application/x-ndjson
+// the last line is {"op":"summary","data":...} or {"op":"error","data":...}
+func streamNDJSON(c *gin.Context, stream chan string, timeout time.Duration, run func(ctx context.Context) *models.ErrorOut, summary func() interface{}) {
+	c.Writer.Header().Set("Content-Type", "application/x-ndjson")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	runCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	// runErr is only read after the stream is closed
+	var runErr *models.ErrorOut
+	go func() {
+		defer close(stream)
+		runErr = run(runCtx)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-stream:
+			if !ok {
+				last := models.StreamEvent{Op: models.EventSummary, Data: summary()}
+				if runErr != nil {
+					last = models.StreamEvent{Op: models.EventError, Data: runErr}
+				}
+				if strObj, err := utils.JsonToText(last); err == nil {
+					writeLine(c, *strObj)
+				}
+				return
+			}
+			writeLine(c, msg)
+		}
+	}
+}
+
+func writeLine(c *gin.Context, line string) {
+	fmt.Fprintln(c.Writer, line)
+	c.Writer.Flush()
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadBatchUrls(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		max         int
+		expect      []string
+		expectError string
+	}{
+		{
+			name:   "Json array",
+			body:   `["https://lucytech.se/", "https://www.home24.de/"]`,
+			max:    5,
+			expect: []string{"https://lucytech.se/", "https://www.home24.de/"},
+		},
+		{
+			name:   "One url per line",
+			body:   "https://lucytech.se/\n\n  https://www.home24.de/  \n",
+			max:    5,
+			expect: []string{"https://lucytech.se/", "https://www.home24.de/"},
+		},
+		{name: "Empty body", body: " \n ", max: 5, expectError: "no urls"},
+		{name: "Empty array", body: "[]", max: 5, expectError: "no urls"},
+		{name: "Invalid json", body: `["https://lucytech.se/"`, max: 5, expectError: "invalid request body"},
+		{name: "Too many urls", body: "https://lucytech.se/\nhttps://www.home24.de/", max: 1, expectError: "too many urls, max 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(tt.body))
+			urls, errObj := readBatchUrls(c, tt.max)
+			if tt.expectError != "" {
+				assert.Nil(t, urls)
+				assert.Equal(t, http.StatusBadRequest, errObj.StatusCode)
+				assert.Equal(t, tt.expectError, errObj.Error)
+				return
+			}
+			assert.Nil(t, errObj)
+			assert.Equal(t, tt.expect, urls)
+		})
+	}
+}
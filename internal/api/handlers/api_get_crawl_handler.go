@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin Api handler used to crawl a site starting from a url
+// depth and pages query params limit the crawl, both are capped by the crawl configs
+// sends a text/event-stream in http1.1 with one event per analyzed page
+func GetCrawlHandler(c *gin.Context) {
+	url := c.Query("url")
+	crawlConfig := configs.GetCrawlConfig()
+
+	startStream(c)
+
+	errObj := utils.UrlValidationCheck(&url)
+	if errObj != nil {
+		writeError(c, "", *errObj)
+		return
+	}
+	depth, errObj := queryLimit(c, "depth", crawlConfig.MaxDepth)
+	if errObj != nil {
+		writeError(c, "", *errObj)
+		return
+	}
+	pages, errObj := queryLimit(c, "pages", crawlConfig.MaxPages)
+	if errObj != nil {
+		writeError(c, "", *errObj)
+		return
+	}
+
+	crawler := analyzers.Crawler{
+		Fetcher:     getFetcher(),
+		Scheduler:   getScheduler(),
+		Robots:      getRobots(),
+		RobotsLinks: configs.GetRobotsConfig().CheckLinks,
+		Stream:      make(chan string, 20),
+		Workers:     runtime.NumCPU(),
+		MaxDepth:    depth,
+		MaxPages:    pages,
+	}
+	streamResults(c, crawler.Stream, crawlConfig.Timeout, func(ctx context.Context) *models.ErrorOut {
+		return crawler.Crawl(ctx, url)
+	}, func() interface{} {
+		return crawler.Output.Summary
+	})
+}
+
+// reads a non negative integer query param capped by max, a missing param defaults to max
+func queryLimit(c *gin.Context, key string, max int) (int, *models.ErrorOut) {
+	raw := c.Query(key)
+	if raw == "" {
+		return max, nil
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 0 {
+		return 0, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid " + key}
+	}
+	if val > max {
+		return max, nil
+	}
+	return val, nil
+}
@@ -2,86 +2,105 @@ package handlers
 
 import (
 	"fmt"
-	"runtime"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/RidmaTP/web-analyzer/internal/configs"
 	"github.com/RidmaTP/web-analyzer/internal/analyzers"
-	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/jobs"
 	"github.com/RidmaTP/web-analyzer/internal/models"
 	"github.com/RidmaTP/web-analyzer/internal/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/patrickmn/go-cache"
 )
 
 // Gin Api handler used to get a url
-// sends a text/event-stream in http1.1
+// sends a text/event-stream in http1.1, or a single json result when asked for json (see wantsJSON)
+// the analysis runs as a job so a client reconnecting with Last-Event-ID gets only the events it missed
+// it is stopped when the client is gone for longer than the resume grace or the analyze timeout is reached
 func GetResultsHandler(c *gin.Context) {
 
 	url := c.Query("url")
 
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Flush()
-
-	ctx := c.Request.Context()
-	a := analyzers.BodyAnalyzer{
-		Fetcher: &fetcher.Fetcher{},
-		Stream:  make(chan string, 20),
-		Output:  models.Output{},
-		Workers: runtime.NumCPU(),
+	if wantsJSON(c) {
+		getResultsJSON(c, url)
+		return
 	}
 
+	startStream(c)
+
 	errObj := utils.UrlValidationCheck(&url)
 	if errObj != nil {
-		fmt.Fprintf(c.Writer, "data: %s\n\n", *utils.ErrStreamObj(*errObj))
-		c.Writer.Flush()
+		writeError(c, "", *errObj)
+		return
+	}
+	checks, errObj := parseChecks(c.Query("checks"))
+	if errObj != nil {
+		writeError(c, "", *errObj)
 		return
 	}
+	key := resultKey(url, checks)
 	fmt.Println(url)
 
-	//checking cache for results for the given url
-	cacheObj := configs.GetCacheConfig()
+	if jobID, sent, ok := lastEventID(c); ok {
+		if job, found := getStreams().Get(jobID); found && job.Url == url {
+			followJob(c, job, sent)
+			cacheResult(key, job)
+			return
+		}
+	}
 
-	if cachedData, found := cacheObj.Get(url); found {
-		a := cachedData.(string)
-		fmt.Fprintf(c.Writer, "data: %s\n\n", a)
-		c.Writer.Flush()
+	//checking cache for results for the given url
+	if cachedData, found := getCachedResult(key); found {
+		writeEvent(c, sseDone, "", cachedData)
 		return
 	}
 
-	errChan := make(chan *models.ErrorOut)
+	job := getStreams().Start(url, newBodyAnalyzer(checks))
+	followJob(c, job, 0)
+	cacheResult(key, job)
+}
 
-	go func(cache *cache.Cache) {
-		defer close(a.Stream)
-		errObj := a.Analyze(url)
-		if errObj != nil {
-			errChan <- errObj
-		}
-		strObj, _ := utils.JsonToText(a.Output)
-		cache.Set(url, *strObj, 2*time.Hour)
+// reads a comma separated list of checks (see analyzers.Register), empty means the default checks
+func parseChecks(raw string) ([]string, *models.ErrorOut) {
+	checks, err := analyzers.ParseChecks(raw)
+	if err != nil {
+		return nil, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: err.Error()}
+	}
+	return checks, nil
+}
 
-	}(cacheObj)
-	for {
-		select {
-		//used to push the error
-		case errObj := <-errChan:
-			if errObj != nil {
-				fmt.Fprintf(c.Writer, "data: %s\n\n", *utils.ErrStreamObj(*errObj))
-				c.Writer.Flush()
+// cache key of a result, the url for the default checks, the url and the sorted checks otherwise
+func resultKey(url string, checks []string) string {
+	if checks == nil {
+		return url
+	}
+	sorted := slices.Clone(checks)
+	slices.Sort(sorted)
+	return url + " checks=" + strings.Join(sorted, ",")
+}
 
-				return
-			}
-		case <-ctx.Done():
-			fmt.Println("client disconnected")
-			return
-		case msg, ok := <-a.Stream:
-			if !ok {
-				return
-			}
-			fmt.Fprintf(c.Writer, "data: %s\n\n", msg)
-			c.Writer.Flush()
-		}
+// caches the result of a finished job
+// only complete results are cached, a failed or aborted analysis is retried on the next request
+// the cached value is the result event (the last event of a done job), so a cache hit is a stream with just the final result
+func cacheResult(key string, job *jobs.Job) {
+	events, _, _ := job.Events(0)
+	if job.Status().Status != jobs.StatusDone || len(events) == 0 {
+		return
+	}
+	setCachedResult(key, events[len(events)-1])
+}
+
+// result event of a url (see resultKey) analyzed within the last 2 hours
+func getCachedResult(key string) (string, bool) {
+	cachedData, found := configs.GetCacheConfig().Get(key)
+	if !found {
+		return "", false
 	}
+	return cachedData.(string), true
+}
+
+func setCachedResult(key string, resultEvent string) {
+	configs.GetCacheConfig().Set(key, resultEvent, 2*time.Hour)
 }
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// json is returned for mode=json or when the client accepts json but not an event stream
+func wantsJSON(c *gin.Context) bool {
+	if mode := c.Query("mode"); mode != "" {
+		return mode == "json"
+	}
+	accept := c.GetHeader("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/event-stream")
+}
+
+// waits for the analysis to finish and responds with the full result and its summary as a single json object
+// errors are returned with their http status, the timeout query param ("30s" or seconds) shortens the analyze timeout
+func getResultsJSON(c *gin.Context, url string) {
+	errObj := utils.UrlValidationCheck(&url)
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	timeout, errObj := queryTimeout(c, configs.GetAnalyzeConfig().Timeout)
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	checks, errObj := parseChecks(c.Query("checks"))
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	key := resultKey(url, checks)
+
+	if cachedData, found := getCachedResult(key); found {
+		writeResultData(c, cachedData)
+		return
+	}
+
+	ctx, cancel := withTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	result, errObj := analyzeToResult(ctx, newBodyAnalyzer(checks), url)
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	setCachedResult(key, result)
+	writeResultData(c, result)
+}
+
+// runs the analysis to the end and returns its result event, the last event of the stream
+func analyzeToResult(ctx context.Context, a *analyzers.BodyAnalyzer, url string) (string, *models.ErrorOut) {
+	errChan := make(chan *models.ErrorOut, 1)
+	go func() {
+		defer close(a.Stream)
+		errChan <- a.Analyze(ctx, url)
+	}()
+	var last string
+	for msg := range a.Stream {
+		last = msg
+	}
+	if errObj := <-errChan; errObj != nil {
+		return "", errObj
+	}
+	return last, nil
+}
+
+// responds with the data of a result event
+func writeResultData(c *gin.Context, resultEvent string) {
+	var event struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(resultEvent), &event); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorOut{StatusCode: http.StatusInternalServerError, Code: models.ErrCodeInternal, Error: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", event.Data)
+}
+
+// reads the timeout query param as a duration ("30s") or a number of seconds, capped by max
+// a missing param defaults to max
+func queryTimeout(c *gin.Context, max time.Duration) (time.Duration, *models.ErrorOut) {
+	raw := c.Query("timeout")
+	if raw == "" {
+		return max, nil
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			timeout = -1
+		} else {
+			timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	if timeout <= 0 {
+		return 0, &models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid timeout"}
+	}
+	if max > 0 && timeout > max {
+		return max, nil
+	}
+	return timeout, nil
+}
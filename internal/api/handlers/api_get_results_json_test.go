@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// gin context of a GET request to the path
+func testContext(path string, headers map[string]string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return c
+}
+
+func Test_WantsJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		expect bool
+	}{
+		{name: "Default is a stream", path: "/result"},
+		{name: "Json mode", path: "/result?mode=json", expect: true},
+		{name: "Mode wins over the accept header", path: "/result?mode=sse", accept: "application/json"},
+		{name: "Accepts json", path: "/result", accept: "application/json", expect: true},
+		{name: "Accepts json and a stream", path: "/result", accept: "application/json, text/event-stream"},
+		{name: "Accepts a stream", path: "/result", accept: "text/event-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testContext(tt.path, map[string]string{"Accept": tt.accept})
+			assert.Equal(t, tt.expect, wantsJSON(c))
+		})
+	}
+}
+
+func Test_QueryTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		max       time.Duration
+		expect    time.Duration
+		expectErr bool
+	}{
+		{name: "Defaults to the max", path: "/result", max: time.Minute, expect: time.Minute},
+		{name: "Duration", path: "/result?timeout=30s", max: time.Minute, expect: 30 * time.Second},
+		{name: "Seconds", path: "/result?timeout=10", max: time.Minute, expect: 10 * time.Second},
+		{name: "Capped by the max", path: "/result?timeout=2m", max: time.Minute, expect: time.Minute},
+		{name: "No max", path: "/result?timeout=2h", expect: 2 * time.Hour},
+		{name: "Zero", path: "/result?timeout=0", max: time.Minute, expectErr: true},
+		{name: "Negative", path: "/result?timeout=-5s", max: time.Minute, expectErr: true},
+		{name: "Not a duration", path: "/result?timeout=soon", max: time.Minute, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, errObj := queryTimeout(testContext(tt.path, nil), tt.max)
+			assert.Equal(t, tt.expectErr, errObj != nil)
+			if errObj != nil {
+				assert.Equal(t, http.StatusBadRequest, errObj.StatusCode)
+				return
+			}
+			assert.Equal(t, tt.expect, timeout)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin Api handler used to analyze every url listed in the sitemaps of a site
+// limit query param caps the analyzed urls, it is capped by the sitemap configs
+// sends a text/event-stream in http1.1 with one event per analyzed url
+func GetSitemapHandler(c *gin.Context) {
+	url := c.Query("url")
+	sitemapConfig := configs.GetSitemapConfig()
+
+	startStream(c)
+
+	errObj := utils.UrlValidationCheck(&url)
+	if errObj != nil {
+		writeError(c, "", *errObj)
+		return
+	}
+	limit, errObj := queryLimit(c, "limit", sitemapConfig.MaxUrls)
+	if errObj != nil {
+		writeError(c, "", *errObj)
+		return
+	}
+
+	sitemapAnalyzer := analyzers.SitemapAnalyzer{
+		Fetcher:     getFetcher(),
+		Scheduler:   getScheduler(),
+		Robots:      getRobots(),
+		RobotsLinks: configs.GetRobotsConfig().CheckLinks,
+		Stream:      make(chan string, 20),
+		Workers:     runtime.NumCPU(),
+		MaxUrls:     limit,
+		MaxSitemaps: sitemapConfig.MaxSitemaps,
+	}
+	streamResults(c, sitemapAnalyzer.Stream, sitemapConfig.Timeout, func(ctx context.Context) *models.ErrorOut {
+		return sitemapAnalyzer.Analyze(ctx, url)
+	}, func() interface{} {
+		return sitemapAnalyzer.Output.Summary
+	})
+}
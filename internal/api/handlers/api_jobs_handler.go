@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin Api handler used to start an analysis in the background
+// expects a json body with the url and optionally the checks to run, returns the created job
+func CreateJobHandler(c *gin.Context) {
+	var input models.Input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid request body"})
+		return
+	}
+	url := input.Url
+	errObj := utils.UrlValidationCheck(&url)
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+	checks, errObj := parseChecks(strings.Join(input.Checks, ","))
+	if errObj != nil {
+		c.JSON(errObj.StatusCode, errObj)
+		return
+	}
+
+	job := getJobs().Start(url, newBodyAnalyzer(checks))
+	c.JSON(http.StatusAccepted, job.Status())
+}
+
+// Gin Api handler used to get the status of a job, the result is included once the job is done
+func GetJobHandler(c *gin.Context) {
+	job, ok := getJobs().Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, jobNotFound())
+		return
+	}
+	c.JSON(http.StatusOK, job.Status())
+}
+
+// Gin Api handler used to cancel a running job
+func DeleteJobHandler(c *gin.Context) {
+	job, cancelled := getJobs().Cancel(c.Param("id"))
+	if job == nil {
+		c.JSON(http.StatusNotFound, jobNotFound())
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, models.ErrorOut{StatusCode: http.StatusConflict, Code: models.ErrCodeConflict, Error: "job already finished"})
+		return
+	}
+	c.JSON(http.StatusOK, job.Status())
+}
+
+// Gin Api handler used to follow a job
+// sends a text/event-stream in http1.1 replaying the events of the job before following the new ones
+// a client reconnecting with Last-Event-ID gets only the events it missed
+// the stream ends with the done event or the error once the job is finished
+func GetJobEventsHandler(c *gin.Context) {
+	job, ok := getJobs().Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, jobNotFound())
+		return
+	}
+
+	from := 0
+	if jobID, sent, ok := lastEventID(c); ok && jobID == job.ID {
+		from = sent
+	}
+	startStream(c)
+	followJob(c, job, from)
+}
+
+func jobNotFound() models.ErrorOut {
+	return models.ErrorOut{StatusCode: http.StatusNotFound, Code: models.ErrCodeNotFound, Error: "job not found"}
+}
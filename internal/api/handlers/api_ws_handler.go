@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// max analyses running at the same time on a single websocket connection
+const maxWsAnalyses = 8
+
+// Gin Api handler used to analyze urls over a websocket
+// the client sends analyze and cancel requests, every analysis streams the same events as the sse api
+// tagged with the id chosen by the client, all the analyses are stopped when the connection is closed
+func WebSocketHandler(c *gin.Context) {
+	server := websocket.Server{
+		// origins are not restricted, same as the cors config of the router
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   serveWs,
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// state of a single websocket connection
+// out is drained by a single writer since a websocket connection does not support concurrent writes
+// running holds the cancel funcs of the analyses by their id
+type wsSession struct {
+	ctx     context.Context
+	out     chan models.WsMessage
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func serveWs(conn *websocket.Conn) {
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+	s := &wsSession{ctx: ctx, out: make(chan models.WsMessage, 64), running: make(map[string]context.CancelFunc)}
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for msg := range s.out {
+			if err := websocket.JSON.Send(conn, msg); err != nil {
+				cancel()
+			}
+		}
+	}()
+
+	for {
+		var raw string
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			break
+		}
+		var req models.WsRequest
+		if err := json.Unmarshal([]byte(raw), &req); err != nil {
+			s.sendError("", models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "invalid message"})
+			continue
+		}
+		s.handle(req)
+	}
+
+	cancel()
+	s.wg.Wait()
+	close(s.out)
+	<-written
+}
+
+func (s *wsSession) handle(req models.WsRequest) {
+	switch req.Type {
+	case models.WsAnalyze:
+		s.start(req.ID, req.Url, req.Checks)
+	case models.WsCancel:
+		s.mu.Lock()
+		cancel, ok := s.running[req.ID]
+		s.mu.Unlock()
+		if !ok {
+			s.sendError(req.ID, models.ErrorOut{StatusCode: http.StatusNotFound, Code: models.ErrCodeNotFound, Error: "analysis not found"})
+			return
+		}
+		cancel()
+	default:
+		s.sendError(req.ID, models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "unknown message type"})
+	}
+}
+
+// starts analyzing the url under the given id
+// the analysis ends with a done event or an error event, a cancelled analysis ends with a CANCELLED error
+func (s *wsSession) start(id, url string, checkNames []string) {
+	if id == "" {
+		s.sendError(id, models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeBadRequest, Error: "missing id"})
+		return
+	}
+	if errObj := utils.UrlValidationCheck(&url); errObj != nil {
+		s.sendError(id, *errObj)
+		return
+	}
+	checks, errObj := parseChecks(strings.Join(checkNames, ","))
+	if errObj != nil {
+		s.sendError(id, *errObj)
+		return
+	}
+	key := resultKey(url, checks)
+
+	s.mu.Lock()
+	if _, ok := s.running[id]; ok {
+		s.mu.Unlock()
+		s.sendError(id, models.ErrorOut{StatusCode: http.StatusConflict, Code: models.ErrCodeConflict, Error: "analysis already running"})
+		return
+	}
+	if len(s.running) >= maxWsAnalyses {
+		s.mu.Unlock()
+		s.sendError(id, models.ErrorOut{StatusCode: http.StatusTooManyRequests, Code: models.ErrCodeTooManyRequests, Error: "too many running analyses", Retryable: true})
+		return
+	}
+	if cachedData, found := getCachedResult(key); found {
+		s.mu.Unlock()
+		s.send(models.WsMessage{ID: id, Event: sseDone, Data: json.RawMessage(cachedData)})
+		return
+	}
+	ctx, cancel := withTimeout(s.ctx, configs.GetAnalyzeConfig().Timeout)
+	s.running[id] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, id)
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.analyze(ctx, id, url, checks)
+	}()
+}
+
+func (s *wsSession) analyze(ctx context.Context, id, url string, checks []string) {
+	a := newBodyAnalyzer(checks)
+	errChan := make(chan *models.ErrorOut, 1)
+	go func() {
+		defer close(a.Stream)
+		errChan <- a.Analyze(ctx, url)
+	}()
+
+	var last string
+	for msg := range a.Stream {
+		s.send(models.WsMessage{ID: id, Event: eventName(msg), Data: json.RawMessage(msg)})
+		last = msg
+	}
+	if errObj := <-errChan; errObj != nil {
+		s.sendError(id, *errObj)
+		return
+	}
+	setCachedResult(resultKey(url, checks), last)
+}
+
+// queues a message for the writer, dropped once the connection is closed
+func (s *wsSession) send(msg models.WsMessage) {
+	select {
+	case s.out <- msg:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *wsSession) sendError(id string, errObj models.ErrorOut) {
+	s.send(models.WsMessage{ID: id, Event: sseError, Data: json.RawMessage(*utils.ErrStreamObj(errObj))})
+}
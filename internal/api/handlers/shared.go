@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/jobs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// fetcher, scheduler, robots.txt cache, job managers and batch slots shared by all the handlers
+// built once so every analysis reuses the same http client and its connection pool,
+// the per host limits of the scheduler hold across concurrent analyses
+// and robots.txt is fetched once per host
+// the analyses of the result api run as jobs of their own manager, so a client can resume them with Last-Event-ID
+var (
+	fetcherOnce     sync.Once
+	sharedFetcher   *fetcher.Fetcher
+	schedulerOnce   sync.Once
+	sharedScheduler *analyzers.HostScheduler
+	robotsOnce      sync.Once
+	sharedRobots    *fetcher.RobotsCache
+	jobsOnce        sync.Once
+	sharedJobs      *jobs.Manager
+	streamsOnce     sync.Once
+	sharedStreams   *jobs.Manager
+	batchSlotsOnce  sync.Once
+	batchSlots      chan struct{}
+)
+
+func getFetcher() *fetcher.Fetcher {
+	fetcherOnce.Do(func() {
+		sharedFetcher = fetcher.NewFetcher(configs.GetFetcherConfig())
+	})
+	return sharedFetcher
+}
+
+func getScheduler() *analyzers.HostScheduler {
+	schedulerOnce.Do(func() {
+		sharedScheduler = analyzers.NewHostScheduler(configs.GetSchedulerConfig())
+	})
+	return sharedScheduler
+}
+
+func getRobots() *fetcher.RobotsCache {
+	robotsOnce.Do(func() {
+		robotsConfig := configs.GetRobotsConfig()
+		sharedRobots = fetcher.NewRobotsCache(getFetcher(), robotsConfig.UserAgent, robotsConfig.CacheTTL)
+	})
+	return sharedRobots
+}
+
+// robots.txt cache used by the link checker, nil when link checks do not respect robots.txt
+func getLinkRobots() *fetcher.RobotsCache {
+	if !configs.GetRobotsConfig().CheckLinks {
+		return nil
+	}
+	return getRobots()
+}
+
+func getJobs() *jobs.Manager {
+	jobsOnce.Do(func() {
+		sharedJobs = jobs.NewManager(configs.GetJobsConfig())
+	})
+	return sharedJobs
+}
+
+// manager of the result api analyses, an analysis is cancelled once its client is gone for the resume grace
+func getStreams() *jobs.Manager {
+	streamsOnce.Do(func() {
+		sseConfig := configs.GetSSEConfig()
+		sharedStreams = jobs.NewManager(models.JobsConfig{
+			ResultTTL:        sseConfig.ReplayTTL,
+			Timeout:          configs.GetAnalyzeConfig().Timeout,
+			CancelUnfollowed: true,
+			UnfollowedGrace:  sseConfig.ResumeGrace,
+		})
+	})
+	return sharedStreams
+}
+
+// slots of the batch analyses, shared so the batch concurrency holds across concurrent batches
+func getBatchSlots() chan struct{} {
+	batchSlotsOnce.Do(func() {
+		batchSlots = make(chan struct{}, max(configs.GetBatchConfig().Concurrency, 1))
+	})
+	return batchSlots
+}
+
+// analyzer set up with the shared fetcher, scheduler and robots.txt cache, nil checks runs the default checks
+func newBodyAnalyzer(checks []string) *analyzers.BodyAnalyzer {
+	return &analyzers.BodyAnalyzer{
+		Fetcher:   getFetcher(),
+		Scheduler: getScheduler(),
+		Robots:    getLinkRobots(),
+		Stream:    make(chan string, 20),
+		Output:    models.Output{},
+		Workers:   runtime.NumCPU(),
+		Checks:    checks,
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/configs"
+	"github.com/RidmaTP/web-analyzer/internal/jobs"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/RidmaTP/web-analyzer/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// server sent event names
+// progress carries the analysis updates, link the found and checked links,
+// done the final result and error the error that ended the stream
+const (
+	sseProgress = "progress"
+	sseLink     = "link"
+	sseError    = "error"
+	sseDone     = "done"
+)
+
+// sets the headers of a text/event-stream response
+func startStream(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Flush()
+}
+
+// writes a single named server sent event and flushes it to the client, an empty id is not sent
+func writeEvent(c *gin.Context, name, id, data string) {
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", name, data)
+	c.Writer.Flush()
+}
+
+func writeError(c *gin.Context, id string, errObj models.ErrorOut) {
+	writeEvent(c, sseError, id, *utils.ErrStreamObj(errObj))
+}
+
+// comment line ignored by EventSource, keeps proxies from closing an idle stream
+func writeHeartbeat(c *gin.Context) {
+	fmt.Fprint(c.Writer, ": heartbeat\n\n")
+	c.Writer.Flush()
+}
+
+// event name of a message streamed by the analyzer, messages without an op (crawl, sitemap pages) are progress
+func eventName(msg string) string {
+	var event struct {
+		Op string `json:"op"`
+	}
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		return sseProgress
+	}
+	switch event.Op {
+	case models.EventLinkFound, models.EventLinkChecked:
+		return sseLink
+	case models.EventResult:
+		return sseDone
+	}
+	return sseProgress
+}
+
+// ticker channel of the heartbeats, nil (never fires) when heartbeats are disabled
+func heartbeat() (<-chan time.Time, func()) {
+	interval := configs.GetSSEConfig().HeartbeatInterval
+	if interval <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// runs the analysis in a goroutine and forwards its stream to the client until the stream is closed
+// run must not close the stream, the error it returns is sent as the last event
+// otherwise the stream ends with the done event carrying the summary returned by summary,
+// a nil summary is used for streams whose last message already is the result
+// the context given to run is cancelled when the client disconnects or the timeout is reached (zero means no timeout)
+func streamResults(c *gin.Context, stream chan string, timeout time.Duration, run func(ctx context.Context) *models.ErrorOut, summary func() interface{}) {
+	ctx := c.Request.Context()
+	runCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	beat, stopBeat := heartbeat()
+	defer stopBeat()
+
+	// runErr is only read after the stream is closed
+	var runErr *models.ErrorOut
+	go func() {
+		defer close(stream)
+		runErr = run(runCtx)
+	}()
+	id := 0
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("client disconnected")
+			return
+		case <-beat:
+			writeHeartbeat(c)
+		case msg, ok := <-stream:
+			id++
+			if !ok {
+				if runErr != nil {
+					writeError(c, strconv.Itoa(id), *runErr)
+				} else if summary == nil {
+					return
+				} else if strObj, err := utils.JsonToText(summary()); err == nil {
+					writeEvent(c, sseDone, strconv.Itoa(id), *strObj)
+				}
+				return
+			}
+			writeEvent(c, eventName(msg), strconv.Itoa(id), msg)
+		}
+	}
+}
+
+// streams the events of a job starting after the event with id from
+// event ids are "<job id>:<n>" with n increasing by one per event, so a client reconnecting with
+// Last-Event-ID gets only the events it missed
+// the stream ends with the done event or the error once the job is finished
+func followJob(c *gin.Context, job *jobs.Job, from int) {
+	unfollow := job.Follow()
+	defer unfollow()
+	beat, stopBeat := heartbeat()
+	defer stopBeat()
+
+	ctx := c.Request.Context()
+	sent := from
+	for {
+		events, updated, finished := job.Events(sent)
+		for _, msg := range events {
+			sent++
+			writeEvent(c, eventName(msg), eventID(job.ID, sent), msg)
+		}
+		if finished {
+			if errObj := job.Status().Error; errObj != nil {
+				writeError(c, eventID(job.ID, sent+1), *errObj)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-beat:
+			writeHeartbeat(c)
+		case <-updated:
+		}
+	}
+}
+
+func eventID(jobID string, n int) string {
+	return jobID + ":" + strconv.Itoa(n)
+}
+
+// parses the Last-Event-ID header sent by a reconnecting client
+// returns the job id and the number of events the client already received
+func lastEventID(c *gin.Context) (string, int, bool) {
+	jobID, raw, ok := strings.Cut(c.GetHeader("Last-Event-ID"), ":")
+	if !ok {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return "", 0, false
+	}
+	return jobID, n, true
+}
+
+// derives a context that is cancelled after timeout, zero means no timeout
+func withTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, timeout)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/jobs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPage = `<html><head><title>Home</title></head><body>
+<h1>Welcome</h1>
+<a href="https://lucytech.se/about">About</a>
+</body></html>`
+
+// a single server sent event of a response
+type sseEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// splits a text/event-stream response into its events, heartbeats are skipped
+func readEvents(body string) []sseEvent {
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.name = value
+			case "data":
+				event.data = value
+			}
+		}
+		if event.name != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// starts a job analyzing the url with the fetcher and waits for it to finish
+func finishedJob(t *testing.T, m *jobs.Manager, url string, f fetcher.BodyFetcher) *jobs.Job {
+	job := m.Start(url, &analyzers.BodyAnalyzer{Fetcher: f, Stream: make(chan string, 20), Workers: 1})
+	timeout := time.After(time.Second)
+	for {
+		_, updated, finished := job.Events(0)
+		if finished {
+			return job
+		}
+		select {
+		case <-updated:
+		case <-timeout:
+			t.Fatal("job did not finish")
+		}
+	}
+}
+
+// sends the request to the handler and returns the events of the response
+func serveEvents(t *testing.T, path, route string, handler gin.HandlerFunc, lastEventID string) []sseEvent {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET(route, handler)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	return readEvents(w.Body.String())
+}
+
+func Test_GetJobEventsHandler_Resume(t *testing.T) {
+	done := finishedJob(t, getJobs(), "https://lucytech.se/", &fetcher.MockFetcher{ResponseBody: testPage})
+	doneEvents, _, _ := done.Events(0)
+	failed := finishedJob(t, getJobs(), "https://lucytech.se/", &fetcher.MockFetcher{ForceReaderErr: true})
+	failedEvents, _, _ := failed.Events(0)
+	assert.Len(t, failedEvents, 1, "only the snapshot is sent before the body is read")
+
+	tests := []struct {
+		name        string
+		job         *jobs.Job
+		lastEventID string
+		expectFirst int
+		expectLast  string
+	}{
+		{name: "Full replay", job: done, expectFirst: 1, expectLast: sseDone},
+		{name: "Resume after the second event", job: done, lastEventID: done.ID + ":2", expectFirst: 3, expectLast: sseDone},
+		{name: "Resume after the last event", job: done, lastEventID: done.ID + ":" + strconv.Itoa(len(doneEvents)), expectFirst: len(doneEvents) + 1},
+		{name: "Id of another job", job: done, lastEventID: failed.ID + ":2", expectFirst: 1, expectLast: sseDone},
+		{name: "Invalid id", job: done, lastEventID: "garbage", expectFirst: 1, expectLast: sseDone},
+		{name: "Failed job", job: failed, expectFirst: 1, expectLast: sseError},
+		{name: "Resume a failed job", job: failed, lastEventID: failed.ID + ":1", expectFirst: 2, expectLast: sseError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobEvents, _, _ := tt.job.Events(0)
+			events := serveEvents(t, "/jobs/"+tt.job.ID+"/events", "/jobs/:id/events", GetJobEventsHandler, tt.lastEventID)
+
+			// the missed events are replayed in order, a failed job ends with its error
+			expected := len(jobEvents) - tt.expectFirst + 1
+			if tt.job.Status().Error != nil {
+				expected++
+			}
+			assert.Len(t, events, expected)
+			for i, event := range events {
+				assert.Equal(t, tt.job.ID+":"+strconv.Itoa(tt.expectFirst+i), event.id)
+				if n := tt.expectFirst + i - 1; n < len(jobEvents) {
+					assert.Equal(t, jobEvents[n], event.data)
+					assert.Equal(t, eventName(jobEvents[n]), event.name)
+				}
+			}
+			if tt.expectLast != "" {
+				assert.Equal(t, tt.expectLast, events[len(events)-1].name)
+			}
+		})
+	}
+}
+
+func Test_GetJobEventsHandler_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/jobs/:id/events", GetJobEventsHandler)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/nope/events", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_GetResultsHandler_Resume(t *testing.T) {
+	url := "https://resume.lucytech.se/"
+	job := finishedJob(t, getStreams(), url, &fetcher.MockFetcher{ResponseBody: testPage})
+	jobEvents, _, _ := job.Events(0)
+
+	events := serveEvents(t, "/result?url="+url, "/result", GetResultsHandler, job.ID+":2")
+	assert.Len(t, events, len(jobEvents)-2)
+	for i, event := range events {
+		assert.Equal(t, job.ID+":"+strconv.Itoa(i+3), event.id)
+		assert.Equal(t, jobEvents[i+2], event.data)
+	}
+	assert.Equal(t, sseDone, events[len(events)-1].name)
+
+	// the resumed result is cached, a request with an unknown job gets it without an id
+	events = serveEvents(t, "/result?url="+url, "/result", GetResultsHandler, "nope:2")
+	assert.Equal(t, []sseEvent{{name: sseDone, data: jobEvents[len(jobEvents)-1]}}, events)
+}
+
+func Test_EventName(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		expect string
+	}{
+		{name: "Link found", msg: `{"op":"link_found","data":{}}`, expect: sseLink},
+		{name: "Link checked", msg: `{"op":"link_checked","data":{}}`, expect: sseLink},
+		{name: "Result", msg: `{"op":"result","data":{}}`, expect: sseDone},
+		{name: "Title", msg: `{"op":"title","data":"Home"}`, expect: sseProgress},
+		{name: "Crawl page without an op", msg: `{"Page":{},"Summary":{}}`, expect: sseProgress},
+		{name: "Invalid json", msg: `not json`, expect: sseProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, eventName(tt.msg))
+		})
+	}
+}
+
+func Test_LastEventID(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		expectJobID string
+		expectSent  int
+		expectOk    bool
+	}{
+		{name: "No header"},
+		{name: "Job id and count", header: "abc:3", expectJobID: "abc", expectSent: 3, expectOk: true},
+		{name: "Nothing received yet", header: "abc:0", expectJobID: "abc", expectOk: true},
+		{name: "Without a count", header: "abc"},
+		{name: "Negative count", header: "abc:-1"},
+		{name: "Count is not a number", header: "abc:x"},
+		{name: "Id of a plain stream", header: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testContext("/", map[string]string{"Last-Event-ID": tt.header})
+			jobID, sent, ok := lastEventID(c)
+			assert.Equal(t, tt.expectJobID, jobID)
+			assert.Equal(t, tt.expectSent, sent)
+			assert.Equal(t, tt.expectOk, ok)
+		})
+	}
+}
+
+func Test_EventID(t *testing.T) {
+	assert.Equal(t, "abc:7", eventID("abc", 7))
+}
@@ -28,5 +28,15 @@ func routes(rg *gin.RouterGroup) {
 	})
 
 	rg.GET("/result" , handlers.GetResultsHandler)
+	rg.GET("/crawl", handlers.GetCrawlHandler)
+	rg.GET("/sitemap", handlers.GetSitemapHandler)
+	rg.GET("/ws", handlers.WebSocketHandler)
+	rg.POST("/batch", handlers.PostBatchHandler)
+	rg.POST("/analyze-html", handlers.PostAnalyzeHtmlHandler)
+
+	rg.POST("/jobs", handlers.CreateJobHandler)
+	rg.GET("/jobs/:id", handlers.GetJobHandler)
+	rg.GET("/jobs/:id/events", handlers.GetJobEventsHandler)
+	rg.DELETE("/jobs/:id", handlers.DeleteJobHandler)
 
 }
@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// deadline of the single page analysis of the result api
+// the analysis is stopped and the in flight link checks are aborted once it is reached
+var (
+	loadAnalyzeOnce sync.Once
+	analyzeConfig   models.AnalyzeConfig
+)
+
+func LoadAnalyzeConfig() models.AnalyzeConfig {
+	loadAnalyzeOnce.Do(func() {
+		analyzeConfig = models.AnalyzeConfig{
+			Timeout: getEnvDuration("ANALYZE_TIMEOUT", 2*time.Minute),
+		}
+	})
+	return analyzeConfig
+}
+
+func GetAnalyzeConfig() models.AnalyzeConfig {
+	return LoadAnalyzeConfig()
+}
@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// limits of the batch analysis
+// a batch with more urls than max urls is rejected, a batch still running after the timeout is stopped
+var (
+	loadBatchOnce sync.Once
+	batchConfig   models.BatchConfig
+)
+
+func LoadBatchConfig() models.BatchConfig {
+	loadBatchOnce.Do(func() {
+		batchConfig = models.BatchConfig{
+			MaxUrls:     getEnvInt("BATCH_MAX_URLS", 1000),
+			Concurrency: getEnvInt("BATCH_CONCURRENCY", 4),
+			Timeout:     getEnvDuration("BATCH_TIMEOUT", 30*time.Minute),
+		}
+	})
+	return batchConfig
+}
+
+func GetBatchConfig() models.BatchConfig {
+	return LoadBatchConfig()
+}
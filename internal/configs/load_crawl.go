@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// upper limits of the crawl mode
+// depth and page budget sent with a request are capped by these values
+// a crawl still running after the timeout is stopped
+var (
+	loadCrawlOnce sync.Once
+	crawlConfig   models.CrawlConfig
+)
+
+func LoadCrawlConfig() models.CrawlConfig {
+	loadCrawlOnce.Do(func() {
+		crawlConfig = models.CrawlConfig{
+			MaxDepth: getEnvInt("CRAWL_MAX_DEPTH", 3),
+			MaxPages: getEnvInt("CRAWL_MAX_PAGES", 50),
+			Timeout:  getEnvDuration("CRAWL_TIMEOUT", 15*time.Minute),
+		}
+	})
+	return crawlConfig
+}
+
+func GetCrawlConfig() models.CrawlConfig {
+	return LoadCrawlConfig()
+}
@@ -2,7 +2,10 @@ package configs
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -44,3 +47,54 @@ func GetPort() string {
 	}
 	return port
 }
+
+// env helpers used by the other config loaders
+func getEnvString(key, fallback string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	val, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	val, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+// comma separated list, empty entries are dropped
+func getEnvList(key string) []string {
+	var list []string
+	for _, val := range strings.Split(os.Getenv(key), ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			list = append(list, val)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	val, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(key)), 64)
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	val, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
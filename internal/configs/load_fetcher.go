@@ -0,0 +1,58 @@
+package configs
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// http client configuration of the fetcher
+// every value can be overridden from configs/.env, invalid or missing values fall back to the defaults
+var (
+	loadFetcherOnce sync.Once
+	fetcherConfig   models.FetcherConfig
+)
+
+const defaultUserAgent = "web-analyzer"
+
+func LoadFetcherConfig() models.FetcherConfig {
+	loadFetcherOnce.Do(func() {
+		fetcherConfig = models.FetcherConfig{
+			ConnectTimeout:      getEnvDuration("FETCH_CONNECT_TIMEOUT", 5*time.Second),
+			ReadTimeout:         getEnvDuration("FETCH_READ_TIMEOUT", 10*time.Second),
+			TotalTimeout:        getEnvDuration("FETCH_TOTAL_TIMEOUT", 30*time.Second),
+			MaxRedirects:        getEnvInt("FETCH_MAX_REDIRECTS", 10),
+			UserAgent:           getEnvString("FETCH_USER_AGENT", defaultUserAgent+"/"+GetAppVersion()),
+			Headers:             parseHeaders(os.Getenv("FETCH_HEADERS")),
+			MaxIdleConnsPerHost: getEnvInt("FETCH_MAX_IDLE_CONNS_PER_HOST", 4),
+			RetryMaxAttempts:    getEnvInt("FETCH_RETRY_MAX_ATTEMPTS", 3),
+			RetryBaseDelay:      getEnvDuration("FETCH_RETRY_BASE_DELAY", 500*time.Millisecond),
+			RetryMaxDelay:       getEnvDuration("FETCH_RETRY_MAX_DELAY", 10*time.Second),
+			BlockPrivateTargets: getEnvBool("FETCH_BLOCK_PRIVATE", true),
+			AllowedCIDRs:        getEnvList("FETCH_ALLOWED_CIDRS"),
+		}
+	})
+	return fetcherConfig
+}
+
+func GetFetcherConfig() models.FetcherConfig {
+	return LoadFetcherConfig()
+}
+
+// extra headers are given as "Key: Value" pairs separated by "|"
+// eg: FETCH_HEADERS = "Accept-Language: en-US|X-Team: web"
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, "|") {
+		key, value, found := strings.Cut(pair, ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			continue
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// job api configuration
+// results of finished jobs are kept in memory for the result ttl
+// jobs running longer than the timeout are stopped
+var (
+	loadJobsOnce sync.Once
+	jobsConfig   models.JobsConfig
+)
+
+func LoadJobsConfig() models.JobsConfig {
+	loadJobsOnce.Do(func() {
+		jobsConfig = models.JobsConfig{
+			ResultTTL: getEnvDuration("JOBS_RESULT_TTL", 2*time.Hour),
+			Timeout:   getEnvDuration("JOBS_TIMEOUT", 10*time.Minute),
+		}
+	})
+	return jobsConfig
+}
+
+func GetJobsConfig() models.JobsConfig {
+	return LoadJobsConfig()
+}
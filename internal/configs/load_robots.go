@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// robots.txt configuration
+// the user agent token is matched against the user-agent groups of robots.txt
+var (
+	loadRobotsOnce sync.Once
+	robotsConfig   models.RobotsConfig
+)
+
+func LoadRobotsConfig() models.RobotsConfig {
+	loadRobotsOnce.Do(func() {
+		robotsConfig = models.RobotsConfig{
+			UserAgent:  getEnvString("ROBOTS_USER_AGENT", defaultUserAgent),
+			CheckLinks: getEnvBool("ROBOTS_CHECK_LINKS", false),
+			CacheTTL:   getEnvDuration("ROBOTS_CACHE_TTL", time.Hour),
+		}
+	})
+	return robotsConfig
+}
+
+func GetRobotsConfig() models.RobotsConfig {
+	return LoadRobotsConfig()
+}
@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// politeness limits of the link check scheduler
+// shared by all the analyses so partner sites are not hammered by concurrent requests
+var (
+	loadSchedulerOnce sync.Once
+	schedulerConfig   models.SchedulerConfig
+)
+
+func LoadSchedulerConfig() models.SchedulerConfig {
+	loadSchedulerOnce.Do(func() {
+		schedulerConfig = models.SchedulerConfig{
+			MaxPerHost: getEnvInt("SCHED_MAX_PER_HOST", 2),
+			HostDelay:  getEnvDuration("SCHED_HOST_DELAY", 200*time.Millisecond),
+			GlobalQPS:  getEnvFloat("SCHED_GLOBAL_QPS", 20),
+		}
+	})
+	return schedulerConfig
+}
+
+func GetSchedulerConfig() models.SchedulerConfig {
+	return LoadSchedulerConfig()
+}
@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// limits of the sitemap driven analysis
+// the url limit sent with a request is capped by max urls
+// an analysis still running after the timeout is stopped
+var (
+	loadSitemapOnce sync.Once
+	sitemapConfig   models.SitemapConfig
+)
+
+func LoadSitemapConfig() models.SitemapConfig {
+	loadSitemapOnce.Do(func() {
+		sitemapConfig = models.SitemapConfig{
+			MaxUrls:     getEnvInt("SITEMAP_MAX_URLS", 100),
+			MaxSitemaps: getEnvInt("SITEMAP_MAX_FILES", 20),
+			Timeout:     getEnvDuration("SITEMAP_TIMEOUT", 15*time.Minute),
+		}
+	})
+	return sitemapConfig
+}
+
+func GetSitemapConfig() models.SitemapConfig {
+	return LoadSitemapConfig()
+}
@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// server sent event stream configs
+// heartbeats keep proxies from cutting idle streams, resume grace and replay ttl bound how long
+// the events of an analysis are kept for clients reconnecting with Last-Event-ID
+var (
+	loadSSEOnce sync.Once
+	sseConfig   models.SSEConfig
+)
+
+func LoadSSEConfig() models.SSEConfig {
+	loadSSEOnce.Do(func() {
+		sseConfig = models.SSEConfig{
+			HeartbeatInterval: getEnvDuration("SSE_HEARTBEAT_INTERVAL", 15*time.Second),
+			ResumeGrace:       getEnvDuration("SSE_RESUME_GRACE", 30*time.Second),
+			ReplayTTL:         getEnvDuration("SSE_REPLAY_TTL", 5*time.Minute),
+		}
+	})
+	return sseConfig
+}
+
+func GetSSEConfig() models.SSEConfig {
+	return LoadSSEConfig()
+}
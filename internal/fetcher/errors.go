@@ -0,0 +1,86 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// error classes used to explain why a link check failed
+const (
+	ErrClassTimeout     = "timeout"
+	ErrClassDNS         = "dns"
+	ErrClassConnRefused = "connection_refused"
+	ErrClassConnReset   = "connection_reset"
+	ErrClassTLS         = "tls"
+	ErrClassHttpStatus  = "http_status"
+	ErrClassRedirects   = "too_many_redirects"
+	ErrClassBlocked     = "blocked_target"
+	ErrClassCancelled   = "cancelled"
+	ErrClassInvalidUrl  = "invalid_url"
+	ErrClassUnknown     = "unknown"
+)
+
+// maps a request error into one of the error classes
+// order matters since a dns or tls error can also be wrapped as a net.OpError
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrClassCancelled
+	}
+	if errors.Is(err, ErrBlockedTarget) {
+		return ErrClassBlocked
+	}
+	if errors.Is(err, ErrTooManyRedirects) {
+		return ErrClassRedirects
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return ErrClassHttpStatus
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return ErrClassTimeout
+		}
+		return ErrClassDNS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrClassTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrClassConnRefused
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return ErrClassConnReset
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthErr) || errors.As(err, &hostnameErr) || errors.As(err, &recordErr) {
+		return ErrClassTLS
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Op == "parse" || strings.Contains(urlErr.Err.Error(), "unsupported protocol scheme") {
+			return ErrClassInvalidUrl
+		}
+	}
+
+	return ErrClassUnknown
+}
@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		expect string
+	}{
+		{
+			name:   "no error",
+			err:    nil,
+			expect: "",
+		},
+		{
+			name:   "dns failure",
+			err:    &url.Error{Op: "Get", URL: "https://nope.invalid", Err: &net.DNSError{Err: "no such host", Name: "nope.invalid"}},
+			expect: ErrClassDNS,
+		},
+		{
+			name:   "timeout",
+			err:    &url.Error{Op: "Get", URL: "https://lucytech.se", Err: os.ErrDeadlineExceeded},
+			expect: ErrClassTimeout,
+		},
+		{
+			name:   "connection refused",
+			err:    &url.Error{Op: "Get", URL: "https://lucytech.se", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+			expect: ErrClassConnRefused,
+		},
+		{
+			name:   "unsupported scheme",
+			err:    &url.Error{Op: "Get", URL: "mailto:a@b.se", Err: errors.New("unsupported protocol scheme \"mailto\"")},
+			expect: ErrClassInvalidUrl,
+		},
+		{
+			name:   "page status",
+			err:    &StatusError{StatusCode: 503},
+			expect: ErrClassHttpStatus,
+		},
+		{
+			name:   "unknown",
+			err:    errors.New("something else"),
+			expect: ErrClassUnknown,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, ClassifyError(tc.err))
+		})
+	}
+}
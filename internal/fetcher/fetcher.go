@@ -1,26 +1,267 @@
 package fetcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
 )
 // fetcher contract
+// the context aborts the in flight request when the analysis is cancelled
 type BodyFetcher interface {
-	FetchBody(url string) (io.ReadCloser, error)
+	FetchBody(ctx context.Context, url string) (io.ReadCloser, error)
+	CheckLink(ctx context.Context, url string) models.LinkResult
+}
+
+// limits the requests sent by a fetcher, eg: the host scheduler of the link checks
+// acquire blocks until a request to the link is allowed, the returned func releases it once the request is done
+type Limiter interface {
+	Acquire(ctx context.Context, link string) (func(), error)
+}
+
+type limiterKey struct{}
+
+// returns a context making every http request sent with it (every attempt, retry and fallback of a link check)
+// wait for the limiter first
+func WithLimiter(ctx context.Context, limiter Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, limiter)
+}
+
+// returned when a request is redirected more than the configured max redirects
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// returned by FetchBody when the page does not respond with 200
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d is returned", e.StatusCode)
+}
+
+// tells if the status is transient (429, 502, 503, 504) so the same request can succeed later
+func (e *StatusError) Temporary() bool {
+	return isRetryableStatus(e.StatusCode)
 }
 
+// max bytes read from a body that is discarded before closing it
+const maxDrainBytes = 64 * 1024
+
+// http fetcher configured through models.FetcherConfig
+// a zero value Fetcher falls back to http.DefaultClient without extra headers
+// sleep is used to wait between retries and can be replaced in tests
+// client sends the link checks, pageClient the page fetches (see NewFetcher)
 type Fetcher struct {
+	client     *http.Client
+	pageClient *http.Client
+	userAgent string
+	headers   map[string]string
+	retry     retryPolicy
+	sleep     func(context.Context, time.Duration) error
+}
+
+// builds a fetcher with its own transport so timeouts, redirect policy and connection pool can be tuned
+// connect timeout applies to dialing, read timeout to waiting for the response headers
+// and total timeout to a whole link check including reading the body
+// a page body is read while it is analyzed, as fast as its links get checked, so page fetches have no total timeout,
+// their deadline is the context (ANALYZE_TIMEOUT)
+// when private targets are blocked the dialer refuses private and reserved addresses outside the allowlist
+// env proxies are not used in that case since the real target could not be verified
+func NewFetcher(config models.FetcherConfig) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.BlockPrivateTargets {
+		dialer.Control = newIPGuard(config.AllowedCIDRs).control
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	transport.ResponseHeaderTimeout = config.ReadTimeout
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport:     transport,
+			Timeout:       config.TotalTimeout,
+			CheckRedirect: redirectPolicy(config.MaxRedirects),
+		},
+		pageClient: &http.Client{
+			Transport:     transport,
+			CheckRedirect: redirectPolicy(config.MaxRedirects),
+		},
+		userAgent: config.UserAgent,
+		headers:   config.Headers,
+		retry: retryPolicy{
+			maxAttempts: config.RetryMaxAttempts,
+			baseDelay:   config.RetryBaseDelay,
+			maxDelay:    config.RetryMaxDelay,
+		},
+		sleep: SleepContext,
+	}
+}
+
+// stops following redirects after maxRedirects hops
+func redirectPolicy(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w : stopped after %d redirects", ErrTooManyRedirects, maxRedirects)
+		}
+		return nil
+	}
 }
 
 // Returns the reader to read the body
-func (f *Fetcher) FetchBody(url string) (io.ReadCloser, error) {
-	resp, err := http.Get(url)
+// the body is not bound by the total timeout, the context of the caller must carry the deadline
+func (f *Fetcher) FetchBody(ctx context.Context, url string) (io.ReadCloser, error) {
+	resp, err := f.do(ctx, f.pageClient, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%d is returned",resp.StatusCode)
+		drainAndClose(resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 	return resp.Body, nil
 }
+
+// checks if the link is available and returns the details of the check
+// transient failures are retried according to the retry policy, the number of retries is kept in the result
+// retries stop as soon as the context is done
+func (f *Fetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	start := time.Now()
+	var result models.LinkResult
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		var retryable bool
+		result, retryAfter, retryable = f.checkOnce(ctx, url)
+		result.Retries = attempt - 1
+		if !retryable || attempt >= f.retry.maxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		delay := f.retry.backoff(attempt)
+		if retryAfter > 0 {
+			delay = f.retry.cap(retryAfter)
+		}
+		if f.sleep != nil && f.sleep(ctx, delay) != nil {
+			break
+		}
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// a single link check attempt
+// a HEAD request is sent first since only the response metadata is needed
+// servers that reject HEAD (405/501) are retried with a GET limited to the first byte
+// any 2xx status counts as active, a ranged GET legitimately answers with 206
+// also returns the delay requested by Retry-After and whether the failure is worth retrying
+func (f *Fetcher) checkOnce(ctx context.Context, url string) (models.LinkResult, time.Duration, bool) {
+	result := models.LinkResult{ResolvedUrl: url, State: models.LinkStateInactive}
+
+	result.Method = http.MethodHead
+	resp, err := f.do(ctx, f.client, http.MethodHead, url, nil)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		drainAndClose(resp.Body)
+		result.Method = http.MethodGet
+		resp, err = f.do(ctx, f.client, http.MethodGet, url, map[string]string{"Range": "bytes=0-0"})
+	}
+	if err != nil {
+		result.ErrorClass = ClassifyError(err)
+		result.Error = err.Error()
+		return result, 0, isRetryableErr(err)
+	}
+	defer drainAndClose(resp.Body)
+
+	result.StatusCode = resp.StatusCode
+	result.FinalUrl = resp.Request.URL.String()
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.ContentLength = contentLength(resp)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.ErrorClass = ErrClassHttpStatus
+		result.Error = fmt.Sprintf("%d is returned", resp.StatusCode)
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return result, retryAfter, isRetryableStatus(resp.StatusCode)
+	}
+	result.State = models.LinkStateActive
+	return result, 0, false
+}
+
+// size of the resource, the total of Content-Range for a ranged GET and Content-Length otherwise, 0 when unknown
+func contentLength(resp *http.Response) int64 {
+	if resp.StatusCode == http.StatusPartialContent {
+		_, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/")
+		size, err := strconv.ParseInt(total, 10, 64)
+		if !ok || err != nil {
+			return 0
+		}
+		return size
+	}
+	return max(resp.ContentLength, 0)
+}
+
+// reads what is left of the body up to maxDrainBytes before closing it
+// so the connection can be reused by the transport instead of being torn down
+func drainAndClose(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
+
+// sends the request through the client with the configured user agent, extra headers and the per request headers
+func (f *Fetcher) do(ctx context.Context, client *http.Client, method, url string, reqHeaders map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range f.headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range reqHeaders {
+		req.Header.Set(key, value)
+	}
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	limiter, _ := ctx.Value(limiterKey{}).(Limiter)
+	if limiter == nil {
+		return client.Do(req)
+	}
+	release, err := limiter.Acquire(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// body of a limited request, the limiter is released once the body is closed
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
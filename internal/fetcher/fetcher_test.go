@@ -0,0 +1,185 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() models.FetcherConfig {
+	return models.FetcherConfig{
+		ConnectTimeout:      time.Second,
+		ReadTimeout:         time.Second,
+		TotalTimeout:        2 * time.Second,
+		MaxRedirects:        2,
+		UserAgent:           "web-analyzer-test",
+		Headers:             map[string]string{"X-Team": "web"},
+		MaxIdleConnsPerHost: 2,
+	}
+}
+
+func TestFetchBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/headers":
+			w.Write([]byte(r.UserAgent() + "," + r.Header.Get("X-Team")))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/slow":
+			time.Sleep(1500 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name      string
+		path      string
+		expectOut string
+		expectErr bool
+	}{
+		{
+			name:      "sends user agent and extra headers",
+			path:      "/headers",
+			expectOut: "web-analyzer-test,web",
+		},
+		{
+			name:      "non 200 status",
+			path:      "/missing",
+			expectErr: true,
+		},
+		{
+			name:      "read timeout",
+			path:      "/slow",
+			expectErr: true,
+		},
+	}
+
+	f := NewFetcher(testConfig())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := f.FetchBody(context.Background(), server.URL+tc.path)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			defer body.Close()
+			out, err := io.ReadAll(body)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectOut, string(out))
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Length", "1234")
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if r.Header.Get("Range") != "bytes=0-0" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Range", "bytes 0-0/5678")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte("<"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name          string
+		path          string
+		state         string
+		method        string
+		statusCode    int
+		errorClass    string
+		finalPath     string
+		contentType   string
+		contentLength int64
+	}{
+		{
+			name:          "active link",
+			path:          "/ok",
+			state:         models.LinkStateActive,
+			method:        http.MethodHead,
+			statusCode:    http.StatusOK,
+			finalPath:     "/ok",
+			contentType:   "text/html",
+			contentLength: 1234,
+		},
+		{
+			name:          "redirected link",
+			path:          "/redirect",
+			state:         models.LinkStateActive,
+			method:        http.MethodHead,
+			statusCode:    http.StatusOK,
+			finalPath:     "/ok",
+			contentType:   "text/html",
+			contentLength: 1234,
+		},
+		{
+			name:       "not found",
+			path:       "/missing",
+			state:      models.LinkStateInactive,
+			method:     http.MethodHead,
+			statusCode: http.StatusNotFound,
+			errorClass: ErrClassHttpStatus,
+			finalPath:  "/missing",
+		},
+		{
+			name:          "HEAD rejected falls back to ranged GET",
+			path:          "/nohead",
+			state:         models.LinkStateActive,
+			method:        http.MethodGet,
+			statusCode:    http.StatusPartialContent,
+			finalPath:     "/nohead",
+			contentType:   "text/html",
+			contentLength: 5678,
+		},
+		{
+			name:       "redirect loop",
+			path:       "/loop",
+			state:      models.LinkStateInactive,
+			errorClass: ErrClassRedirects,
+		},
+	}
+
+	f := NewFetcher(testConfig())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := f.CheckLink(context.Background(), server.URL+tc.path)
+			assert.Equal(t, server.URL+tc.path, result.ResolvedUrl)
+			assert.Equal(t, tc.state, result.State)
+			assert.Equal(t, tc.statusCode, result.StatusCode)
+			if tc.method != "" {
+				assert.Equal(t, tc.method, result.Method)
+			}
+			assert.Equal(t, tc.errorClass, result.ErrorClass)
+			assert.Equal(t, tc.contentType, result.ContentType)
+			assert.Equal(t, tc.contentLength, result.ContentLength)
+			if tc.finalPath != "" {
+				assert.Equal(t, server.URL+tc.finalPath, result.FinalUrl)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// fetcher of local html files, used to analyze a static site without serving it
+// file:// urls are read from root, the url path /docs/index.html is the file <root>/docs/index.html
+// a directory is served by its index.html, missing files fail like a 404
+// other urls are passed on to remote, a nil remote reports them as inactive without a request
+type FileFetcher struct {
+	Root   string
+	Remote BodyFetcher
+}
+
+// tells if the fetcher can check file:// links, a ReaderFetcher can when its remote is a FileFetcher
+func ServesFiles(f BodyFetcher) bool {
+	switch f := f.(type) {
+	case *FileFetcher:
+		return true
+	case *ReaderFetcher:
+		return ServesFiles(f.Remote)
+	}
+	return false
+}
+
+// returned for urls that can not be fetched when there is no remote fetcher
+var ErrNoRemote = errors.New("remote urls are not fetched")
+
+// builds the file:// url of a file under root
+func FileUrl(root, file string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absFile)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not under %s", file, root)
+	}
+	return (&url.URL{Scheme: "file", Path: "/" + filepath.ToSlash(rel)}).String(), nil
+}
+
+func (f *FileFetcher) FetchBody(ctx context.Context, link string) (io.ReadCloser, error) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "file" {
+		if f.Remote == nil {
+			return nil, ErrNoRemote
+		}
+		return f.Remote.FetchBody(ctx, link)
+	}
+	file, err := os.Open(f.path(u))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &StatusError{StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// checks if the file of a file:// link exists, other links are checked by the remote fetcher
+func (f *FileFetcher) CheckLink(ctx context.Context, link string) models.LinkResult {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "file" {
+		if f.Remote == nil {
+			return models.LinkResult{ResolvedUrl: link, State: models.LinkStateInactive, ErrorClass: ErrClassUnknown, Error: ErrNoRemote.Error()}
+		}
+		return f.Remote.CheckLink(ctx, link)
+	}
+
+	start := time.Now()
+	result := models.LinkResult{ResolvedUrl: link, FinalUrl: link, State: models.LinkStateActive, StatusCode: http.StatusOK}
+	if _, err := os.Stat(f.path(u)); err != nil {
+		result = models.LinkResult{ResolvedUrl: link, State: models.LinkStateInactive, StatusCode: http.StatusNotFound, ErrorClass: ErrClassHttpStatus, Error: err.Error()}
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// file path of a file:// url, the url path is cleaned so it can not leave root
+func (f *FileFetcher) path(u *url.URL) string {
+	name := filepath.Join(f.Root, filepath.FromSlash(path.Clean("/"+u.Path)))
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		name = filepath.Join(name, "index.html")
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileFetcher(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<title>Home</title>"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("<title>Docs</title>"), 0o644))
+	f := &FileFetcher{Root: root, Remote: &MockFetcher{ResponseBody: "remote"}}
+	ctx := context.Background()
+
+	testCases := []struct {
+		name   string
+		url    string
+		expect string
+		status int
+	}{
+		{"file", "file:///index.html", "<title>Home</title>", 0},
+		{"directory index", "file:///docs/", "<title>Docs</title>", 0},
+		{"can not leave root", "file:///../../index.html", "<title>Home</title>", 0},
+		{"missing file", "file:///missing.html", "", http.StatusNotFound},
+		{"remote", "https://lucytech.se/", "remote", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := f.FetchBody(ctx, tc.url)
+			if tc.status != 0 {
+				var statusErr *StatusError
+				assert.ErrorAs(t, err, &statusErr)
+				assert.Equal(t, tc.status, statusErr.StatusCode)
+				return
+			}
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(body)
+			body.Close()
+			assert.Equal(t, tc.expect, string(data))
+		})
+	}
+
+	assert.Equal(t, models.LinkStateActive, f.CheckLink(ctx, "file:///docs").State)
+	assert.Equal(t, models.LinkStateInactive, f.CheckLink(ctx, "file:///missing.html").State)
+	assert.Equal(t, models.LinkStateActive, f.CheckLink(ctx, "https://lucytech.se/").State)
+
+	offline := &FileFetcher{Root: root}
+	_, err := offline.FetchBody(ctx, "https://lucytech.se/")
+	assert.ErrorIs(t, err, ErrNoRemote)
+	assert.Equal(t, models.LinkStateInactive, offline.CheckLink(ctx, "https://lucytech.se/").State)
+}
+
+func TestFileUrl(t *testing.T) {
+	root := t.TempDir()
+	link, err := FileUrl(root, filepath.Join(root, "docs", "my page.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///docs/my%20page.html", link)
+
+	_, err = FileUrl(filepath.Join(root, "docs"), filepath.Join(root, "index.html"))
+	assert.Error(t, err)
+}
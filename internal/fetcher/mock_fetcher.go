@@ -1,32 +1,75 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
 )
 // This is used to mock the FetchBody using Fetcher interface
 // can force errors to test fetcher errors
+// when pages is set the body is looked up by url (without the fragment) and unknown urls fail like a 404
+// ReaderErr replaces the simulated read error of ForceReaderErr
 
 type MockFetcher struct {
 	ResponseBody   string
+	Pages          map[string]string
 	ForceErr       bool
 	ForceReaderErr bool
+	ReaderErr      error
 }
 
-func (f *MockFetcher) FetchBody(url string) (io.ReadCloser, error) {
+func (f *MockFetcher) FetchBody(ctx context.Context, url string) (io.ReadCloser, error) {
 	if f.ForceErr {
 		return nil, errors.New("mock err")
 	}
 	if f.ForceReaderErr {
-		return &ErrorReader{}, nil
+		return &ErrorReader{Err: f.ReaderErr}, nil
+	}
+	if f.Pages != nil {
+		page, ok := f.Pages[stripFragment(url)]
+		if !ok {
+			return nil, &StatusError{StatusCode: http.StatusNotFound}
+		}
+		return io.NopCloser(strings.NewReader(page)), nil
 	}
 	return io.NopCloser(strings.NewReader(f.ResponseBody)), nil
 }
 
-type ErrorReader struct{}
+func (f *MockFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	_, found := f.Pages[stripFragment(url)]
+	if f.ForceErr || (f.Pages != nil && !found) {
+		return models.LinkResult{
+			ResolvedUrl: url,
+			State:       models.LinkStateInactive,
+			ErrorClass:  ErrClassUnknown,
+			Error:       "mock err",
+		}
+	}
+	return models.LinkResult{
+		ResolvedUrl: url,
+		FinalUrl:    url,
+		State:       models.LinkStateActive,
+		StatusCode:  http.StatusOK,
+	}
+}
+
+// fragments are never sent to a server so they are ignored when looking up pages
+func stripFragment(url string) string {
+	return strings.Split(url, "#")[0]
+}
+
+type ErrorReader struct {
+	Err error
+}
 
 func (e *ErrorReader) Read(p []byte) (int, error) {
+	if e.Err != nil {
+		return 0, e.Err
+	}
 	return 0, errors.New("simulated read error")
 }
 
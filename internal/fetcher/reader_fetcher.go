@@ -0,0 +1,38 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// url of raw html analyzed without a base url, its relative links can only be checked by a fetcher of file:// urls
+const RawPageUrl = "file:///index.html"
+
+// fetcher of a page given as raw html (an upload or stdin) instead of being fetched
+// FetchBody returns the html for the page url, url only resolves the relative links of the page
+// other urls and the link checks go to remote, a nil remote fails them like FileFetcher does
+type ReaderFetcher struct {
+	Url    string
+	Html   []byte
+	Remote BodyFetcher
+}
+
+func (f *ReaderFetcher) FetchBody(ctx context.Context, url string) (io.ReadCloser, error) {
+	if url == f.Url {
+		return io.NopCloser(bytes.NewReader(f.Html)), nil
+	}
+	if f.Remote == nil {
+		return nil, ErrNoRemote
+	}
+	return f.Remote.FetchBody(ctx, url)
+}
+
+func (f *ReaderFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	if f.Remote == nil {
+		return models.LinkResult{ResolvedUrl: url, State: models.LinkStateInactive, ErrorClass: ErrClassUnknown, Error: ErrNoRemote.Error()}
+	}
+	return f.Remote.CheckLink(ctx, url)
+}
@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderFetcher(t *testing.T) {
+	ctx := context.Background()
+	f := &ReaderFetcher{
+		Url:    "https://lucytech.se/",
+		Html:   []byte("<title>Upload</title>"),
+		Remote: &MockFetcher{Pages: map[string]string{"https://lucytech.se/about": "about"}},
+	}
+
+	// the html can be read more than once
+	for i := 0; i < 2; i++ {
+		body, err := f.FetchBody(ctx, "https://lucytech.se/")
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "<title>Upload</title>", string(data))
+	}
+
+	body, err := f.FetchBody(ctx, "https://lucytech.se/about")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "about", string(data))
+
+	assert.Equal(t, models.LinkStateActive, f.CheckLink(ctx, "https://lucytech.se/about").State)
+	assert.Equal(t, models.LinkStateInactive, f.CheckLink(ctx, "https://lucytech.se/missing").State)
+
+	offline := &ReaderFetcher{Url: RawPageUrl, Html: []byte("<title>Upload</title>")}
+	_, err = offline.FetchBody(ctx, "https://lucytech.se/about")
+	assert.ErrorIs(t, err, ErrNoRemote)
+	assert.Equal(t, models.LinkStateInactive, offline.CheckLink(ctx, "https://lucytech.se/about").State)
+}
+
+func TestServesFiles(t *testing.T) {
+	assert.True(t, ServesFiles(&FileFetcher{}))
+	assert.True(t, ServesFiles(&ReaderFetcher{Remote: &FileFetcher{}}))
+	assert.False(t, ServesFiles(&ReaderFetcher{Remote: &MockFetcher{}}))
+	assert.False(t, ServesFiles(&ReaderFetcher{}))
+	assert.False(t, ServesFiles(&MockFetcher{}))
+}
@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// retry policy applied to link checks
+// transient failures (rate limiting, unavailable upstreams, resets and timeouts) are retried
+// with an exponential backoff and full jitter, a Retry-After header sent by the server takes precedence
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// delay before the next attempt, attempt starts from 1
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	if p.baseDelay <= 0 {
+		return 0
+	}
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 0 || (p.maxDelay > 0 && delay > p.maxDelay) {
+		delay = p.maxDelay
+	}
+	return time.Duration(rand.Int64N(int64(delay) + 1))
+}
+
+// cap of the delay requested by the server when no max delay is configured
+const defaultMaxRetryAfter = 30 * time.Second
+
+// caps the delay requested by the server so a single link can not stall a worker
+// the delay is always capped, by defaultMaxRetryAfter when the policy has no max delay
+func (p retryPolicy) cap(delay time.Duration) time.Duration {
+	limit := p.maxDelay
+	if limit <= 0 {
+		limit = defaultMaxRetryAfter
+	}
+	return min(delay, limit)
+}
+
+// waits for the delay, returns early with the context error when the context is done
+// a delay of 0 or less does not wait but still reports a done context
+func SleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests ||
+		statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
+
+func isRetryableErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	return ClassifyError(err) == ErrClassTimeout
+}
+
+// parses the Retry-After header which is either in seconds or a http date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
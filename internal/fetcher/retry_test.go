@@ -0,0 +1,215 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLinkRetry(t *testing.T) {
+	testCases := []struct {
+		name         string
+		failures     int32
+		status       int
+		retryAfter   string
+		expectState  string
+		expectTries  int32
+		expectDelays []time.Duration
+	}{
+		{
+			name:        "recovers after transient 503",
+			failures:    2,
+			status:      http.StatusServiceUnavailable,
+			expectState: models.LinkStateActive,
+			expectTries: 3,
+		},
+		{
+			name:        "gives up after max attempts",
+			failures:    5,
+			status:      http.StatusTooManyRequests,
+			expectState: models.LinkStateInactive,
+			expectTries: 3,
+		},
+		{
+			name:        "does not retry 404",
+			failures:    5,
+			status:      http.StatusNotFound,
+			expectState: models.LinkStateInactive,
+			expectTries: 1,
+		},
+		{
+			name:         "honors retry after",
+			failures:     1,
+			status:       http.StatusTooManyRequests,
+			retryAfter:   "2",
+			expectState:  models.LinkStateActive,
+			expectTries:  2,
+			expectDelays: []time.Duration{2 * time.Second},
+		},
+		{
+			name:         "caps retry after",
+			failures:     1,
+			status:       http.StatusServiceUnavailable,
+			retryAfter:   "120",
+			expectState:  models.LinkStateActive,
+			expectTries:  2,
+			expectDelays: []time.Duration{5 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var tries int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&tries, 1) <= tc.failures {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(tc.status)
+				}
+			}))
+			defer server.Close()
+
+			config := testConfig()
+			config.RetryMaxAttempts = 3
+			config.RetryBaseDelay = time.Second
+			config.RetryMaxDelay = 5 * time.Second
+			f := NewFetcher(config)
+			var delays []time.Duration
+			f.sleep = func(ctx context.Context, d time.Duration) error { delays = append(delays, d); return nil }
+
+			result := f.CheckLink(context.Background(), server.URL)
+			assert.Equal(t, tc.expectState, result.State)
+			assert.Equal(t, tc.expectTries, atomic.LoadInt32(&tries))
+			assert.Equal(t, int(tc.expectTries)-1, result.Retries)
+			if tc.expectDelays != nil {
+				assert.Equal(t, tc.expectDelays, delays)
+			}
+			for _, d := range delays {
+				assert.LessOrEqual(t, d, config.RetryMaxDelay)
+			}
+		})
+	}
+}
+
+func TestRetryAfterCap(t *testing.T) {
+	policy := retryPolicy{maxDelay: 5 * time.Second}
+	assert.Equal(t, 2*time.Second, policy.cap(2*time.Second))
+	assert.Equal(t, 5*time.Second, policy.cap(time.Hour))
+
+	// without a max delay the server can not make a worker sleep for long either
+	policy = retryPolicy{}
+	assert.Equal(t, 2*time.Second, policy.cap(2*time.Second))
+	assert.Equal(t, defaultMaxRetryAfter, policy.cap(time.Hour))
+}
+
+func TestCheckLinkCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := testConfig()
+	config.RetryMaxAttempts = 3
+	f := NewFetcher(config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result := f.CheckLink(ctx, server.URL)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, models.LinkStateInactive, result.State)
+	assert.Equal(t, 0, result.Retries)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name        string
+		value       string
+		expectDelay time.Duration
+		expectOk    bool
+	}{
+		{"seconds", "30", 30 * time.Second, true},
+		{"http date", now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{"date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"empty", "", 0, false},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tc.value, now)
+			assert.Equal(t, tc.expectOk, ok)
+			assert.Equal(t, tc.expectDelay, delay)
+		})
+	}
+}
+
+// counts the acquired and released requests
+type countingLimiter struct {
+	acquired, released int32
+}
+
+func (l *countingLimiter) Acquire(ctx context.Context, link string) (func(), error) {
+	atomic.AddInt32(&l.acquired, 1)
+	return func() { atomic.AddInt32(&l.released, 1) }, nil
+}
+
+func TestCheckLinkLimiter(t *testing.T) {
+	var tries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HEAD is rejected so every attempt falls back to a GET, the first attempt is throttled
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if atomic.AddInt32(&tries, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.RetryMaxAttempts = 3
+	f := NewFetcher(config)
+	f.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+	limiter := &countingLimiter{}
+	result := f.CheckLink(WithLimiter(context.Background(), limiter), server.URL)
+	assert.Equal(t, models.LinkStateActive, result.State)
+	assert.Equal(t, 1, result.Retries)
+	// 2 attempts of a HEAD and a GET each
+	assert.Equal(t, int32(4), atomic.LoadInt32(&limiter.acquired))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&limiter.released))
+}
+
+func TestCheckLinkLimiterCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := NewFetcher(testConfig())
+	result := f.CheckLink(WithLimiter(ctx, blockingLimiter{}), server.URL)
+	assert.Equal(t, models.LinkStateInactive, result.State)
+}
+
+// never allows a request before the context is done
+type blockingLimiter struct{}
+
+func (blockingLimiter) Acquire(ctx context.Context, link string) (func(), error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
@@ -0,0 +1,274 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// max bytes of a robots.txt that are parsed, the rest is ignored
+const maxRobotsBytes = 500 * 1024
+
+// rules of a robots.txt that apply to our user agent token
+// sitemaps are listed independent of the user agent groups
+type RobotsRules struct {
+	rules      []robotsRule
+	CrawlDelay time.Duration
+	Sitemaps   []string
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// parses a robots.txt and keeps the group that matches the user agent token
+// the most specific matching group wins, the * group is used when no group matches
+func ParseRobots(r io.Reader, userAgent string) *RobotsRules {
+	type group struct {
+		agents     []string
+		rules      []robotsRule
+		crawlDelay time.Duration
+	}
+	var groups []*group
+	var current *group
+	sitemaps := []string{}
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsBytes))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); current != nil && err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+		lastWasAgent = false
+	}
+
+	token := strings.ToLower(userAgent)
+	var matched *group
+	matchLen := -1
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" && matchLen < 0 {
+				matched, matchLen = g, 0
+			} else if agent != "*" && strings.Contains(token, agent) && len(agent) > matchLen {
+				matched, matchLen = g, len(agent)
+			}
+		}
+	}
+
+	rules := &RobotsRules{Sitemaps: sitemaps}
+	if matched != nil {
+		rules.rules = matched.rules
+		rules.CrawlDelay = matched.crawlDelay
+	}
+	return rules
+}
+
+// reports if the path (with the query) can be fetched
+// the longest matching rule wins and allow wins a tie, no matching rule means allowed
+func (r *RobotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+	allowed, matchLen := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > matchLen || (len(rule.pattern) == matchLen && rule.allow) {
+			allowed, matchLen = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// matches a robots.txt path pattern supporting the * wildcard and the $ end anchor
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored && len(parts) > 1 {
+		return strings.HasSuffix(path, parts[len(parts)-1])
+	}
+	return !anchored || rest == ""
+}
+
+// hosts kept by the robots cache, expired entries and then the oldest ones are evicted past this
+const maxRobotsHosts = 1024
+
+// deadline of a robots.txt fetch, it is not bound to the context of an analysis
+const robotsFetchTimeout = 30 * time.Second
+
+// a server error on robots.txt is cached for at most this long, so a short outage does not block a host for the whole ttl
+const robotsErrorTTL = time.Minute
+
+// per host cache of robots.txt rules
+// a robots.txt that can not be fetched is treated as allow all, except for server errors (5xx) that disallow
+// everything as asked by RFC 9309
+// the cache is shared by many analyses, so robots.txt is fetched without the context of the analysis
+// that requested it, otherwise a cancelled analysis would cache an allow all for the host
+type RobotsCache struct {
+	fetcher   BodyFetcher
+	userAgent string
+	ttl       time.Duration
+	mu        sync.Mutex
+	hosts     map[string]*robotsEntry
+}
+
+// once makes sure the robots.txt of a host is only fetched once even when requested concurrently
+type robotsEntry struct {
+	once    sync.Once
+	rules   *RobotsRules
+	expires time.Time
+}
+
+func NewRobotsCache(fetcher BodyFetcher, userAgent string, ttl time.Duration) *RobotsCache {
+	return &RobotsCache{
+		fetcher:   fetcher,
+		userAgent: userAgent,
+		ttl:       ttl,
+		hosts:     make(map[string]*robotsEntry),
+	}
+}
+
+// returns the rules of the host of the link, nil when the link can not be parsed
+func (c *RobotsCache) Rules(link string) *RobotsRules {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	key := u.Scheme + "://" + strings.ToLower(u.Host)
+
+	c.mu.Lock()
+	entry, ok := c.hosts[key]
+	if !ok || time.Now().After(entry.expires) {
+		if !ok && len(c.hosts) >= maxRobotsHosts {
+			c.evict()
+		}
+		entry = &robotsEntry{expires: time.Now().Add(c.ttl)}
+		c.hosts[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		var serverErr bool
+		entry.rules, serverErr = c.fetch(key + "/robots.txt")
+		if serverErr {
+			c.mu.Lock()
+			entry.expires = time.Now().Add(min(c.ttl, robotsErrorTTL))
+			c.mu.Unlock()
+		}
+	})
+	return entry.rules
+}
+
+// removes the expired hosts, or the host expiring first when none has expired
+// must be called while holding mu
+func (c *RobotsCache) evict() {
+	now := time.Now()
+	var oldest string
+	for host, entry := range c.hosts {
+		if now.After(entry.expires) {
+			delete(c.hosts, host)
+		} else if oldest == "" || entry.expires.Before(c.hosts[oldest].expires) {
+			oldest = host
+		}
+	}
+	if len(c.hosts) >= maxRobotsHosts {
+		delete(c.hosts, oldest)
+	}
+}
+
+// crawl-delay of the host of the link, 0 when robots.txt has none or there is no cache
+func (c *RobotsCache) CrawlDelay(link string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	if rules := c.Rules(link); rules != nil {
+		return rules.CrawlDelay
+	}
+	return 0
+}
+
+// reports if the link can be fetched according to the robots.txt of its host
+func (c *RobotsCache) Allowed(link string) bool {
+	if c == nil {
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return true
+	}
+	return c.Rules(link).Allowed(u.RequestURI())
+}
+
+// fetches and parses the robots.txt, also reports whether the server failed with a 5xx
+func (c *RobotsCache) fetch(robotsUrl string) (*RobotsRules, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), robotsFetchTimeout)
+	defer cancel()
+	body, err := c.fetcher.FetchBody(ctx, robotsUrl)
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode >= 500 {
+			return disallowAll(), true
+		}
+		return &RobotsRules{}, false
+	}
+	defer body.Close()
+	return ParseRobots(body, c.userAgent), false
+}
+
+func disallowAll() *RobotsRules {
+	return &RobotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+}
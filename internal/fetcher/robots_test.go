@@ -0,0 +1,131 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `
+# comment line
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: otherbot
+Disallow: /
+
+User-agent: web-analyzer
+User-agent: someone-else
+Disallow: /admin
+Crawl-delay: 0.5
+
+Sitemap: https://lucytech.se/sitemap.xml
+`
+
+func TestRobotsAllowed(t *testing.T) {
+	testCases := []struct {
+		name      string
+		userAgent string
+		path      string
+		expect    bool
+	}{
+		{"wildcard group disallow", "somebot", "/private/page.html", false},
+		{"longer allow wins", "somebot", "/private/public.html", true},
+		{"wildcard and end anchor", "somebot", "/docs/file.pdf", false},
+		{"end anchor does not match longer path", "somebot", "/docs/file.pdf?x=1", true},
+		{"not matched path", "somebot", "/about", true},
+		{"specific group disallow all", "OtherBot/1.0", "/about", false},
+		{"specific group replaces wildcard group", "web-analyzer/v1.1", "/private/page.html", true},
+		{"specific group disallow", "web-analyzer/v1.1", "/admin/users", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := ParseRobots(strings.NewReader(testRobots), tc.userAgent)
+			assert.Equal(t, tc.expect, rules.Allowed(tc.path))
+		})
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := ParseRobots(strings.NewReader(testRobots), "web-analyzer")
+	assert.Equal(t, 500*time.Millisecond, rules.CrawlDelay)
+	assert.Equal(t, []string{"https://lucytech.se/sitemap.xml"}, rules.Sitemaps)
+
+	rules = ParseRobots(strings.NewReader(testRobots), "somebot")
+	assert.Equal(t, 2*time.Second, rules.CrawlDelay)
+}
+
+func TestRobotsCache(t *testing.T) {
+	mock := &MockFetcher{Pages: map[string]string{
+		"https://lucytech.se/robots.txt": testRobots,
+	}}
+	cache := NewRobotsCache(mock, "somebot", time.Hour)
+
+	assert.False(t, cache.Allowed("https://lucytech.se/private/page.html"))
+	assert.True(t, cache.Allowed("https://lucytech.se/about"))
+	// hosts without a robots.txt allow everything
+	assert.True(t, cache.Allowed("https://www.home24.de/private/page.html"))
+
+	assert.Equal(t, 2*time.Second, cache.CrawlDelay("https://lucytech.se/about"))
+	assert.Equal(t, time.Duration(0), cache.CrawlDelay("https://www.home24.de/"))
+
+	var nilCache *RobotsCache
+	assert.True(t, nilCache.Allowed("https://lucytech.se/private/page.html"))
+	assert.Equal(t, time.Duration(0), nilCache.CrawlDelay("https://lucytech.se/about"))
+}
+
+// fails every request with the status
+type statusFetcher struct {
+	MockFetcher
+	status int
+}
+
+func (f *statusFetcher) FetchBody(ctx context.Context, url string) (io.ReadCloser, error) {
+	return nil, &StatusError{StatusCode: f.status}
+}
+
+func TestRobotsCacheErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		expectAllow bool
+	}{
+		{"missing robots.txt allows everything", http.StatusNotFound, true},
+		{"forbidden robots.txt allows everything", http.StatusForbidden, true},
+		{"server error disallows everything", http.StatusInternalServerError, false},
+		{"unavailable disallows everything", http.StatusServiceUnavailable, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewRobotsCache(&statusFetcher{status: tc.status}, "somebot", time.Hour)
+			assert.Equal(t, tc.expectAllow, cache.Allowed("https://lucytech.se/about"))
+			assert.Equal(t, tc.expectAllow, cache.Allowed("https://lucytech.se/"))
+		})
+	}
+
+	// a server error is not cached for the whole ttl
+	cache := NewRobotsCache(&statusFetcher{status: http.StatusServiceUnavailable}, "somebot", time.Hour)
+	cache.Allowed("https://lucytech.se/")
+	assert.WithinDuration(t, time.Now().Add(robotsErrorTTL), cache.hosts["https://lucytech.se"].expires, time.Second)
+}
+
+func TestRobotsCacheEviction(t *testing.T) {
+	cache := NewRobotsCache(&MockFetcher{Pages: map[string]string{}}, "somebot", time.Hour)
+	for i := 0; i < maxRobotsHosts+10; i++ {
+		cache.Allowed(fmt.Sprintf("https://host%d.lucytech.se/", i))
+	}
+	assert.Len(t, cache.hosts, maxRobotsHosts)
+	// the hosts seen last are kept
+	assert.Contains(t, cache.hosts, fmt.Sprintf("https://host%d.lucytech.se", maxRobotsHosts+9))
+}
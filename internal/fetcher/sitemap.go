@@ -0,0 +1,53 @@
+package fetcher
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// max uncompressed size of a sitemap, same limit as the sitemaps protocol
+const maxSitemapBytes = 50 * 1024 * 1024
+
+// a sitemap is either an urlset listing pages or a sitemap index listing other sitemaps
+type sitemapDoc struct {
+	Urls     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// parses a sitemap or a sitemap index and returns the listed page urls and the nested sitemap urls
+// gzipped sitemaps are detected by their magic bytes since .xml.gz files are usually served without a content encoding
+func ParseSitemap(r io.Reader) ([]string, []string, error) {
+	reader := bufio.NewReader(r)
+	var content io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gzipReader.Close()
+		content = gzipReader
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(content, maxSitemapBytes)).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	return locs(doc.Urls), locs(doc.Sitemaps), nil
+}
+
+func locs(entries []sitemapLoc) []string {
+	var urls []string
+	for _, entry := range entries {
+		if loc := strings.TrimSpace(entry.Loc); loc != "" {
+			urls = append(urls, loc)
+		}
+	}
+	return urls
+}
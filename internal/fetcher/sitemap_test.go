@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testUrlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://lucytech.se/</loc></url>
+	<url><loc> https://lucytech.se/about </loc><lastmod>2025-01-01</lastmod></url>
+</urlset>`
+
+const testSitemapIndex = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://lucytech.se/sitemap-pages.xml</loc></sitemap>
+	<sitemap><loc>https://lucytech.se/sitemap-blog.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+func gzipString(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestParseSitemap(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectUrls     []string
+		expectSitemaps []string
+		expectErr      bool
+	}{
+		{
+			name:       "urlset",
+			content:    testUrlset,
+			expectUrls: []string{"https://lucytech.se/", "https://lucytech.se/about"},
+		},
+		{
+			name:           "sitemap index",
+			content:        testSitemapIndex,
+			expectSitemaps: []string{"https://lucytech.se/sitemap-pages.xml", "https://lucytech.se/sitemap-blog.xml.gz"},
+		},
+		{
+			name:       "gzipped urlset",
+			content:    gzipString(t, testUrlset),
+			expectUrls: []string{"https://lucytech.se/", "https://lucytech.se/about"},
+		},
+		{
+			name:      "not a sitemap",
+			content:   "<html><body>not found</body",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			urls, sitemaps, err := ParseSitemap(strings.NewReader(tc.content))
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectUrls, urls)
+			assert.Equal(t, tc.expectSitemaps, sitemaps)
+		})
+	}
+}
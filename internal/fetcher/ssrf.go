@@ -0,0 +1,108 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// returned when a connection to a private or reserved address is refused
+var ErrBlockedTarget = errors.New("target address is not allowed")
+
+// reserved ranges that are not covered by the net.IP helpers
+var reservedNets = parseCIDRs([]string{
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+})
+
+// dialer level guard against server side request forgery
+// the check runs on the address the dialer is about to connect to, after the hostname is resolved
+// so it also covers redirects and dns rebinding, every new connection is checked again
+// addresses inside the allowlist are always allowed
+type ipGuard struct {
+	allowed []*net.IPNet
+}
+
+func newIPGuard(allowedCIDRs []string) *ipGuard {
+	return &ipGuard{allowed: parseCIDRs(allowedCIDRs)}
+}
+
+// used as net.Dialer.Control
+func (g *ipGuard) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w : %s", ErrBlockedTarget, address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || (isBlockedIP(ip) && !g.isAllowed(ip)) {
+		return fmt.Errorf("%w : %s", ErrBlockedTarget, host)
+	}
+	return nil
+}
+
+func (g *ipGuard) isAllowed(ip net.IP) bool {
+	for _, ipNet := range g.allowed {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports if the ip is private, loopback, link local or otherwise reserved
+func isBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, ipNet := range reservedNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parses CIDRs and single ips, invalid entries are skipped
+func parseCIDRs(values []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				continue
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
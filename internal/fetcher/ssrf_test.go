@@ -0,0 +1,86 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ip     string
+		expect bool
+	}{
+		{"loopback", "127.0.0.1", true},
+		{"private 10.x", "10.1.2.3", true},
+		{"private 192.168.x", "192.168.1.1", true},
+		{"cloud metadata", "169.254.169.254", true},
+		{"carrier grade nat", "100.64.0.1", true},
+		{"unspecified", "0.0.0.0", true},
+		{"ipv6 loopback", "::1", true},
+		{"ipv6 unique local", "fd00::1", true},
+		{"ipv4 mapped loopback", "::ffff:127.0.0.1", true},
+		{"public ipv4", "93.184.216.34", false},
+		{"public ipv6", "2606:2800:220:1:248:1893:25c8:1946", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, isBlockedIP(net.ParseIP(tc.ip)))
+		})
+	}
+}
+
+func TestBlockPrivateTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name         string
+		allowedCIDRs []string
+		expectState  string
+		expectClass  string
+	}{
+		{
+			name:        "loopback server is blocked",
+			expectState: models.LinkStateInactive,
+			expectClass: ErrClassBlocked,
+		},
+		{
+			name:         "allowlisted range is allowed",
+			allowedCIDRs: []string{"127.0.0.0/8"},
+			expectState:  models.LinkStateActive,
+		},
+		{
+			name:         "allowlisted single ip is allowed",
+			allowedCIDRs: []string{"127.0.0.1"},
+			expectState:  models.LinkStateActive,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig()
+			config.BlockPrivateTargets = true
+			config.AllowedCIDRs = tc.allowedCIDRs
+			f := NewFetcher(config)
+
+			result := f.CheckLink(context.Background(), server.URL+"/redirect")
+			assert.Equal(t, tc.expectState, result.State)
+			assert.Equal(t, tc.expectClass, result.ErrorClass)
+
+			_, err := f.FetchBody(context.Background(), server.URL)
+			assert.Equal(t, tc.expectClass != "", err != nil)
+		})
+	}
+}
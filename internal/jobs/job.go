@@ -0,0 +1,172 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// job states
+const (
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// a single analysis started through the job api
+// events are all the messages streamed by the analyzer, they are delta events so a follower needs every one of them
+// updated is closed and replaced whenever an event is added or the job is finished
+// followers is the number of clients following the job, see Follow
+type Job struct {
+	ID               string
+	Url              string
+	mu               sync.Mutex
+	status           string
+	createdAt        time.Time
+	finishedAt       time.Time
+	output           *models.Output
+	errObj           *models.ErrorOut
+	events           []string
+	updated          chan struct{}
+	cancel           context.CancelFunc
+	followers        int
+	cancelUnfollowed bool
+	grace            time.Duration
+	graceTimer       *time.Timer
+}
+
+// runs the analyzer and forwards its stream to the subscribers until the analysis is over
+// a cancelled job is already finished, so the error returned by the aborted analysis is dropped by finish
+func (j *Job) run(ctx context.Context, a *analyzers.BodyAnalyzer) {
+	defer j.cancel()
+
+	errChan := make(chan *models.ErrorOut, 1)
+	go func() {
+		defer close(a.Stream)
+		errChan <- a.Analyze(ctx, j.Url)
+	}()
+	for msg := range a.Stream {
+		j.publish(msg)
+	}
+
+	errObj := <-errChan
+	if errObj != nil {
+		j.finish(StatusFailed, nil, errObj)
+		return
+	}
+	j.finish(StatusDone, &a.Output, nil)
+}
+
+// cancels the job, returns false when the job was already finished
+func (j *Job) Cancel() bool {
+	cancelled := j.finish(StatusCancelled, nil, &models.ErrorOut{StatusCode: http.StatusGone, Code: models.ErrCodeCancelled, Error: "job cancelled"})
+	if cancelled {
+		j.cancel()
+	}
+	return cancelled
+}
+
+// registers a client following the job, the returned func must be called once the client is gone
+// jobs of a manager with cancelUnfollowed are cancelled when nobody follows them for the grace period,
+// a client reconnecting within the grace period keeps the job running
+func (j *Job) Follow() func() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.followers++
+	if j.graceTimer != nil {
+		j.graceTimer.Stop()
+		j.graceTimer = nil
+	}
+	return sync.OnceFunc(j.unfollow)
+}
+
+func (j *Job) unfollow() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.followers--
+	if j.followers > 0 || !j.cancelUnfollowed || j.status != StatusRunning {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(j.grace, func() {
+		j.mu.Lock()
+		abandoned := j.graceTimer == timer
+		j.mu.Unlock()
+		if abandoned && j.finish(StatusCancelled, nil, &models.ErrorOut{StatusCode: statusClientClosedRequest, Code: models.ErrCodeCancelled, Error: "client disconnected", Retryable: true}) {
+			j.cancel()
+		}
+	})
+	j.graceTimer = timer
+}
+
+// status used for jobs cancelled because their client went away
+const statusClientClosedRequest = 499
+
+// adds a stream message to the events of the job and wakes up the followers
+func (j *Job) publish(msg string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != StatusRunning {
+		return
+	}
+	j.events = append(j.events, msg)
+	j.notify()
+}
+
+// must be called while holding mu
+func (j *Job) notify() {
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// moves the job into a final state, returns false when the job was already finished
+func (j *Job) finish(status string, output *models.Output, errObj *models.ErrorOut) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != StatusRunning {
+		return false
+	}
+	j.status, j.output, j.errObj = status, output, errObj
+	j.finishedAt = time.Now()
+	j.notify()
+	return true
+}
+
+// returns the events of the job starting at index from
+// updated is closed once more events are added or the job is finished, finished means no more events will follow
+// a follower keeps calling Events with the number of events it has read so far until the job is finished
+func (j *Job) Events(from int) (events []string, updated <-chan struct{}, finished bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if from < 0 {
+		from = 0
+	}
+	if from < len(j.events) {
+		events = j.events[from:len(j.events):len(j.events)]
+	}
+	return events, j.updated, j.status != StatusRunning
+}
+
+// current state of the job as returned by the job api
+func (j *Job) Status() models.JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := models.JobStatus{
+		ID:        j.ID,
+		Url:       j.Url,
+		Status:    j.status,
+		CreatedAt: j.createdAt,
+		Error:     j.errObj,
+		Result:    j.output,
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	return status
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+)
+
+// keeps track of the analyses started through the job api
+// an analysis runs independent of any client connection, finished jobs are kept for ttl so their results can be read later
+// an analysis still running after timeout is stopped, zero means no timeout
+// with cancelUnfollowed an analysis is tied to its followers and cancelled once nobody followed it for grace
+type Manager struct {
+	mu               sync.Mutex
+	jobs             map[string]*Job
+	ttl              time.Duration
+	timeout          time.Duration
+	cancelUnfollowed bool
+	grace            time.Duration
+}
+
+func NewManager(config models.JobsConfig) *Manager {
+	return &Manager{
+		jobs:             make(map[string]*Job),
+		ttl:              config.ResultTTL,
+		timeout:          config.Timeout,
+		cancelUnfollowed: config.CancelUnfollowed,
+		grace:            config.UnfollowedGrace,
+	}
+}
+
+// starts analyzing the url in the background with the given analyzer and returns the job
+// the analyzer must not be used by the caller afterwards
+func (m *Manager) Start(url string, a *analyzers.BodyAnalyzer) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	if m.timeout > 0 {
+		cancel()
+		ctx, cancel = context.WithTimeout(context.Background(), m.timeout)
+	}
+	job := &Job{
+		ID:               newID(),
+		Url:              url,
+		status:           StatusRunning,
+		createdAt:        time.Now(),
+		updated:          make(chan struct{}),
+		cancel:           cancel,
+		cancelUnfollowed: m.cancelUnfollowed,
+		grace:            m.grace,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	go func() {
+		job.run(ctx, a)
+		time.AfterFunc(m.ttl, func() { m.remove(job.ID) })
+	}()
+	return job
+}
+
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// cancels a running job, returns false when the job is unknown or already finished
+func (m *Manager) Cancel(id string) (*Job, bool) {
+	job, ok := m.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return job, job.Cancel()
+}
+
+func (m *Manager) remove(id string) {
+	m.mu.Lock()
+	delete(m.jobs, id)
+	m.mu.Unlock()
+}
+
+// random 128 bit id encoded as hex
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
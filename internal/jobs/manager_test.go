@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RidmaTP/web-analyzer/internal/analyzers"
+	"github.com/RidmaTP/web-analyzer/internal/fetcher"
+	"github.com/RidmaTP/web-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fetcher that blocks until the context of the request is done, used to keep a job running
+// stopped is closed once the request gave up
+type blockingFetcher struct {
+	stopped chan struct{}
+}
+
+func (f *blockingFetcher) FetchBody(ctx context.Context, url string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	close(f.stopped)
+	return nil, ctx.Err()
+}
+
+func (f *blockingFetcher) CheckLink(ctx context.Context, url string) models.LinkResult {
+	return models.LinkResult{}
+}
+
+func newAnalyzer(f fetcher.BodyFetcher) *analyzers.BodyAnalyzer {
+	return &analyzers.BodyAnalyzer{
+		Fetcher: f,
+		Stream:  make(chan string, 20),
+		Workers: 1,
+	}
+}
+
+func waitFinished(t *testing.T, job *Job) models.JobStatus {
+	timeout := time.After(time.Second)
+	for {
+		_, updated, finished := job.Events(0)
+		if finished {
+			return job.Status()
+		}
+		select {
+		case <-updated:
+		case <-timeout:
+			t.Fatal("job did not finish")
+		}
+	}
+}
+
+func Test_Manager(t *testing.T) {
+	tests := []struct {
+		name         string
+		fetcher      fetcher.BodyFetcher
+		expectStatus string
+		expectTitle  string
+		expectErr    bool
+	}{
+		{
+			name:         "Job done",
+			fetcher:      &fetcher.MockFetcher{ResponseBody: "<html><head><title>Test Page</title></head></html>"},
+			expectStatus: StatusDone,
+			expectTitle:  "Test Page",
+		},
+		{
+			name:         "Job failed",
+			fetcher:      &fetcher.MockFetcher{ForceErr: true},
+			expectStatus: StatusFailed,
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(models.JobsConfig{ResultTTL: time.Hour})
+			job := m.Start("https://lucytech.se/", newAnalyzer(tt.fetcher))
+
+			found, ok := m.Get(job.ID)
+			assert.True(t, ok)
+			assert.Equal(t, job, found)
+
+			status := waitFinished(t, job)
+			assert.Equal(t, tt.expectStatus, status.Status)
+			assert.NotNil(t, status.FinishedAt)
+			assert.Equal(t, tt.expectErr, status.Error != nil)
+			if tt.expectTitle != "" {
+				assert.Equal(t, tt.expectTitle, status.Result.Title)
+			}
+
+			_, cancelled := m.Cancel(job.ID)
+			assert.False(t, cancelled, "finished job must not be cancelled")
+		})
+	}
+}
+
+func Test_Manager_Cancel(t *testing.T) {
+	m := NewManager(models.JobsConfig{ResultTTL: time.Hour})
+	f := &blockingFetcher{stopped: make(chan struct{})}
+	job := m.Start("https://lucytech.se/", newAnalyzer(f))
+
+	assert.Equal(t, StatusRunning, job.Status().Status)
+	_, cancelled := m.Cancel(job.ID)
+	assert.True(t, cancelled)
+
+	status := waitFinished(t, job)
+	assert.Equal(t, StatusCancelled, status.Status)
+	assert.Nil(t, status.Result)
+
+	select {
+	case <-f.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("analysis was not stopped")
+	}
+}
+
+func Test_Manager_Timeout(t *testing.T) {
+	m := NewManager(models.JobsConfig{ResultTTL: time.Hour, Timeout: 20 * time.Millisecond})
+	f := &blockingFetcher{stopped: make(chan struct{})}
+	job := m.Start("https://lucytech.se/", newAnalyzer(f))
+
+	status := waitFinished(t, job)
+	assert.Equal(t, StatusFailed, status.Status)
+	if assert.NotNil(t, status.Error) {
+		assert.Equal(t, http.StatusGatewayTimeout, status.Error.StatusCode)
+	}
+}
+
+func Test_Manager_Expiry(t *testing.T) {
+	m := NewManager(models.JobsConfig{ResultTTL: 10 * time.Millisecond})
+	job := m.Start("https://lucytech.se/", newAnalyzer(&fetcher.MockFetcher{ResponseBody: "<html></html>"}))
+	waitFinished(t, job)
+
+	assert.Eventually(t, func() bool {
+		_, ok := m.Get(job.ID)
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	_, ok := m.Get("unknown")
+	assert.False(t, ok)
+}
+
+func Test_Job_Events(t *testing.T) {
+	job := &Job{status: StatusRunning, updated: make(chan struct{}), cancel: func() {}}
+	events, updated, finished := job.Events(0)
+	assert.Empty(t, events)
+	assert.False(t, finished)
+
+	job.publish(`{"op":"title","data":"a"}`)
+	<-updated
+	job.publish(`{"op":"version","data":"HTML5"}`)
+
+	events, _, _ = job.Events(0)
+	assert.Equal(t, []string{`{"op":"title","data":"a"}`, `{"op":"version","data":"HTML5"}`}, events)
+	events, updated, _ = job.Events(1)
+	assert.Equal(t, []string{`{"op":"version","data":"HTML5"}`}, events)
+	events, _, _ = job.Events(5)
+	assert.Empty(t, events)
+
+	job.finish(StatusDone, &models.Output{}, nil)
+	<-updated
+	events, _, finished = job.Events(2)
+	assert.Empty(t, events)
+	assert.True(t, finished)
+
+	job.publish(`{"op":"title","data":"b"}`)
+	events, _, _ = job.Events(0)
+	assert.Len(t, events, 2, "events must not be added once the job is finished")
+}
+
+func Test_Job_Follow(t *testing.T) {
+	m := NewManager(models.JobsConfig{ResultTTL: time.Hour, CancelUnfollowed: true, UnfollowedGrace: 30 * time.Millisecond})
+	f := &blockingFetcher{stopped: make(chan struct{})}
+	job := m.Start("https://lucytech.se/", newAnalyzer(f))
+
+	// a follower coming back within the grace period keeps the job running
+	unfollow := job.Follow()
+	unfollow()
+	unfollow = job.Follow()
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, StatusRunning, job.Status().Status)
+
+	unfollow()
+	status := waitFinished(t, job)
+	assert.Equal(t, StatusCancelled, status.Status)
+	if assert.NotNil(t, status.Error) {
+		assert.Equal(t, statusClientClosedRequest, status.Error.StatusCode)
+	}
+	select {
+	case <-f.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("analysis was not stopped")
+	}
+}
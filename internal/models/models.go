@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // all the data models will be listed here (since there are few models, included everything in one file)
 type Output struct {
 	Version       string
@@ -9,7 +14,10 @@ type Output struct {
 	ExternalLinks LinksData
 	ActiveLinks   LinksData
 	InactiveLinks LinksData
+	BlockedLinks  LinksData
 	IsLogin       bool
+	LinkResults   []LinkResult
+	Sections      map[string]interface{} `json:",omitempty"`
 }
 
 type LinksData struct {
@@ -17,8 +25,394 @@ type LinksData struct {
 	Links []string
 }
 
+// ops of the events streamed by the analyzer
+// a stream starts with a snapshot of the output, followed by delta events and ends with the full result
+const (
+	EventSnapshot    = "snapshot"
+	EventVersion     = "version"
+	EventTitle       = "title"
+	EventHeader      = "header"
+	EventLinkFound   = "link_found"
+	EventLinkChecked = "link_checked"
+	EventLogin       = "login"
+	EventSection     = "section"
+	EventResult      = "result"
+)
+
+// single event of the analysis stream, data depends on the op
+// snapshot carries an Output, result an AnalysisResult, link_checked a LinkResult, header a HeaderCount, link_found a LinkFound
+// version and title carry a string, login a bool and section a Section
+type StreamEvent struct {
+	Op   string      `json:"op"`
+	Data interface{} `json:"data"`
+}
+
+// the full output of a finished analysis with its summary stats, sent as the result event
+type AnalysisResult struct {
+	Output
+	Summary AnalysisSummary
+}
+
+// summary stats of a finished analysis
+// headers is the total of all the header tags, retries the total of the link check retries
+type AnalysisSummary struct {
+	Headers       int
+	InternalLinks int
+	ExternalLinks int
+	ActiveLinks   int
+	InactiveLinks int
+	BlockedLinks  int
+	Retries       int
+	IsLogin       bool
+	DurationMs    int64
+}
+
+// new count of a header tag
+type HeaderCount struct {
+	Tag   string
+	Count int
+}
+
+// output section added by a check once it is finalized, name is the name the check is registered with
+type Section struct {
+	Name string
+	Data interface{}
+}
+
+// seo section of the output
+// open graph and twitter card tags are keyed by their property or name (og:title, twitter:card)
+// canonicalCheck is the check result of the canonical url, canonicalSelf is set when it points to the analyzed page
+type SeoSection struct {
+	Description    string
+	Robots         string
+	Canonical      string
+	CanonicalCheck *LinkResult
+	CanonicalSelf  bool
+	OpenGraph      map[string]string
+	TwitterCard    map[string]string
+	Issues         []SeoIssue
+}
+
+// a missing, duplicate or invalid seo value, field is the tag (description, canonical, og:title, ...)
+type SeoIssue struct {
+	Field  string
+	Issue  string
+	Detail string
+}
+
+// issues reported by the seo check
+const (
+	SeoIssueMissing   = "missing"
+	SeoIssueEmpty     = "empty"
+	SeoIssueDuplicate = "duplicate"
+	SeoIssueTooLong   = "too_long"
+	SeoIssueNoIndex   = "noindex"
+	SeoIssueBroken    = "broken"
+	SeoIssueNotSelf   = "not_self_referential"
+)
+
+// outline section of the output, the headings in document order and the issues of the hierarchy
+type OutlineSection struct {
+	Headings []OutlineHeading
+	Issues   []OutlineIssue
+}
+
+// a heading of the outline, order is its position among the headings of the page starting at 1
+// hidden is set for headings that are hidden themselves or inside a hidden element
+type OutlineHeading struct {
+	Order  int
+	Level  int
+	Text   string
+	Hidden bool
+}
+
+// an issue of the outline, order is the heading it is about (0 for the issues of the whole page)
+type OutlineIssue struct {
+	Issue  string
+	Order  int
+	Detail string
+}
+
+// issues reported by the outline check
+const (
+	OutlineIssueMissingH1    = "missing_h1"
+	OutlineIssueMultipleH1   = "multiple_h1"
+	OutlineIssueSkippedLevel = "skipped_level"
+	OutlineIssueEmpty        = "empty_heading"
+	OutlineIssueHidden       = "hidden_heading"
+)
+
+// accessibility section of the output, the findings are sorted by line
+type A11ySection struct {
+	Findings []A11yFinding
+	Errors   int
+	Warnings int
+}
+
+// a failed accessibility rule, wcag is the success criterion the rule is about
+// snippet is the start tag of the element, line is 0 for findings about the whole page
+type A11yFinding struct {
+	Rule     string
+	Severity string
+	Wcag     string
+	Message  string
+	Snippet  string
+	Line     int
+}
+
+// severities of the accessibility findings
+const (
+	A11ySeverityError   = "error"
+	A11ySeverityWarning = "warning"
+)
+
+// images section of the output, total counts the image references, missing alt and missing dimensions the img elements
+// and broken and oversized the distinct image urls
+type ImagesSection struct {
+	Images            []ImageRef
+	Total             int
+	Broken            int
+	MissingAlt        int
+	MissingDimensions int
+	Oversized         int
+}
+
+// an image referenced by the page, attribute is src, srcset or style (css background images)
+// alt, width and height are the attributes of the img element, check is the check result of the url
+type ImageRef struct {
+	Url       string
+	Tag       string
+	Attribute string
+	Line      int
+	Alt       string
+	HasAlt    bool
+	Width     string
+	Height    string
+	Check     *LinkResult
+	Issues    []string
+}
+
+// issues reported by the images check
+const (
+	ImageIssueBroken            = "broken"
+	ImageIssueMissingAlt        = "missing_alt"
+	ImageIssueMissingDimensions = "missing_dimensions"
+	ImageIssueOversized         = "oversized"
+)
+
+// resources section of the output, total and third party count the resource references and broken the distinct
+// resource urls that are not available, categories has the same counts per category
+// third party hosts counts the references per host of the third party resources
+type ResourcesSection struct {
+	Resources       []Resource
+	Total           int
+	ThirdParty      int
+	Broken          int
+	Categories      map[string]ResourceCount
+	ThirdPartyHosts map[string]int
+}
+
+type ResourceCount struct {
+	Total      int
+	ThirdParty int
+	Broken     int
+}
+
+// a subresource loaded by the page, hint is the rel of the preload hints (preload, modulepreload, prefetch)
+// check is the check result of the url, nil for urls that can not be requested (data:, about:, javascript:)
+type Resource struct {
+	Url       string
+	Category  string
+	Origin    string
+	Tag       string
+	Attribute string
+	Hint      string
+	Line      int
+	Check     *LinkResult
+	Broken    bool
+}
+
+// categories of the resources
+const (
+	ResourceScript     = "script"
+	ResourceStylesheet = "stylesheet"
+	ResourceFont       = "font"
+	ResourceImage      = "image"
+	ResourceIframe     = "iframe"
+	ResourceMedia      = "media"
+	ResourceEmbed      = "embed"
+	ResourceObject     = "object"
+	ResourceOther      = "other"
+)
+
+// origins of the resources, third party resources are served from another host than the page or its subdomains
+const (
+	ResourceOriginFirstParty = "first_party"
+	ResourceOriginThirdParty = "third_party"
+)
+
+// a link found in the html body, internal is false for links to other hosts
+type LinkFound struct {
+	Url       string
+	Internal  bool
+	Tag       string
+	Attribute string
+}
+
+// link check states used in LinkResult
+// blocked by robots means the link was not requested since robots.txt disallows it
+const (
+	LinkStateActive          = "active"
+	LinkStateInactive        = "inactive"
+	LinkStateBlockedByRobots = "blocked_by_robots"
+)
+
+// a link pushed into the link check job queue
+// tag and attribute keep track of where the link was found in the html body
+// done is set by checks verifying their own links, the result is handed to it from the worker
+// instead of being added to the links of the output
+type LinkJob struct {
+	Url       string
+	Tag       string
+	Attribute string
+	Done      func(LinkResult)
+}
+
+// detailed result of a single link check
+// url is the link as found in the page, resolvedUrl is the absolute url that was checked
+// finalUrl is the url after following redirects, method is the http method that produced the result
+// retries is the number of extra attempts made after transient failures, latency includes them
+// contentLength is the size of the resource from the response headers, 0 when unknown
+type LinkResult struct {
+	Url           string
+	ResolvedUrl   string
+	FinalUrl      string
+	State         string
+	Method        string
+	StatusCode    int
+	ErrorClass    string
+	Error         string
+	Retries       int
+	LatencyMs     int64
+	ContentType   string
+	ContentLength int64
+	Tag           string
+	Attribute     string
+}
+
+// result of a single page analyzed by the crawler
+type CrawlPage struct {
+	Url    string
+	Depth  int
+	Error  string
+	Output Output
+}
+
+// site wide aggregate of a crawl, pages blocked are internal pages skipped because of robots.txt
+type CrawlSummary struct {
+	PagesAnalyzed     int
+	PagesFailed       int
+	TotalBrokenLinks  int
+	PagesWithoutTitle []string
+	LoginPages        []string
+	PagesBlocked      []string
+}
+
+// streamed after every crawled page, page is the page that was just analyzed
+type CrawlOutput struct {
+	Page    *CrawlPage
+	Summary CrawlSummary
+}
+
+// a url listed in a sitemap that could not be analyzed
+type SitemapFailure struct {
+	Url   string
+	Error string
+}
+
+// aggregate of a sitemap driven analysis
+// missingFromSitemap are internal links found on the analyzed pages that no sitemap lists
+type SitemapSummary struct {
+	Sitemaps           []string
+	UrlsListed         int
+	PagesAnalyzed      int
+	FailedUrls         []SitemapFailure
+	MissingFromSitemap []string
+	PagesBlocked       []string
+}
+
+// streamed after every analyzed sitemap url, page is the page that was just analyzed
+type SitemapOutput struct {
+	Page    *CrawlPage
+	Summary SitemapSummary
+}
+
+// result of a single url of a batch, index is the position of the url in the request
+// output is set when the analysis succeeded, error otherwise
+type BatchItem struct {
+	Index  int
+	Url    string
+	Error  *ErrorOut
+	Output *Output
+}
+
+// aggregate of a batch analysis, broken links are counted once even when multiple pages link to them
+type BatchSummary struct {
+	UrlsTotal         int
+	Analyzed          int
+	Failed            int
+	TotalBrokenLinks  int
+	PagesWithoutTitle []string
+	LoginPages        []string
+	DurationMs        int64
+}
+
+// ops of the batch stream, one url_result per url followed by the summary
+const (
+	EventUrlResult = "url_result"
+	EventSummary   = "summary"
+	EventError     = "error"
+)
+
+// checks are the names of the checks to run, empty runs the default checks
 type Input struct {
-	Url string `json:"url"`
+	Url    string   `json:"url"`
+	Checks []string `json:"checks"`
+}
+
+// message sent by a websocket client
+// analyze starts analyzing url under the client chosen id, cancel stops the analysis with the id
+type WsRequest struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id"`
+	Url    string   `json:"url"`
+	Checks []string `json:"checks"`
+}
+
+// websocket request types
+const (
+	WsAnalyze = "analyze"
+	WsCancel  = "cancel"
+)
+
+// message sent to a websocket client, event and data are the same as the name and data of the sse events
+// id is the id of the analysis the event belongs to
+type WsMessage struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// state of an analysis started through the job api
+// result is only set once the job is done
+type JobStatus struct {
+	ID         string     `json:"id"`
+	Url        string     `json:"url"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      *ErrorOut  `json:"error,omitempty"`
+	Result     *Output    `json:"result,omitempty"`
 }
 
 type LoginFlags struct {
@@ -30,7 +424,111 @@ type LoginFlags struct {
 	InButton        bool
 }
 
+// error returned by the api and sent as the error event of a stream
+// code is one of the ErrCode values, retryable tells if the same request can succeed later
 type ErrorOut struct {
-	StatusCode int
-	Error      string
+	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
+	Error      string `json:"error"`
+	Retryable  bool   `json:"retryable"`
+}
+
+// machine readable error codes of ErrorOut
+const (
+	ErrCodeInvalidUrl       = "INVALID_URL"
+	ErrCodeBadRequest       = "BAD_REQUEST"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeTooManyRequests  = "TOO_MANY_REQUESTS"
+	ErrCodeFetchTimeout     = "FETCH_TIMEOUT"
+	ErrCodeDNSFailure       = "DNS_FAILURE"
+	ErrCodeConnectionFailed = "CONNECTION_FAILED"
+	ErrCodeTLSFailure       = "TLS_FAILURE"
+	ErrCodeTooManyRedirects = "TOO_MANY_REDIRECTS"
+	ErrCodeBlockedTarget    = "BLOCKED_TARGET"
+	ErrCodeUpstreamStatus   = "UPSTREAM_STATUS"
+	ErrCodeFetchFailed      = "FETCH_FAILED"
+	ErrCodeRobotsDisallowed = "ROBOTS_DISALLOWED"
+	ErrCodeNoSitemap        = "NO_SITEMAP"
+	ErrCodeAnalysisTimeout  = "ANALYSIS_TIMEOUT"
+	ErrCodeCancelled        = "CANCELLED"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+)
+
+// http client options used to construct the fetcher
+// loaded from env through the configs package
+type FetcherConfig struct {
+	ConnectTimeout      time.Duration
+	ReadTimeout         time.Duration
+	TotalTimeout        time.Duration
+	MaxRedirects        int
+	UserAgent           string
+	Headers             map[string]string
+	MaxIdleConnsPerHost int
+	RetryMaxAttempts    int
+	RetryBaseDelay      time.Duration
+	RetryMaxDelay       time.Duration
+	BlockPrivateTargets bool
+	AllowedCIDRs        []string
+}
+
+// limits applied by the link check scheduler
+// loaded from env through the configs package
+type SchedulerConfig struct {
+	MaxPerHost int
+	HostDelay  time.Duration
+	GlobalQPS  float64
+}
+
+// limits of the crawl mode, requests can only lower them
+type CrawlConfig struct {
+	MaxDepth int
+	MaxPages int
+	Timeout  time.Duration
+}
+
+// robots.txt handling, userAgent is the token matched against the user-agent groups
+// checkLinks makes the link checker respect robots.txt too, the crawler always does
+type RobotsConfig struct {
+	UserAgent  string
+	CheckLinks bool
+	CacheTTL   time.Duration
+}
+
+// limits of the sitemap driven analysis, requests can only lower the url limit
+type SitemapConfig struct {
+	MaxUrls     int
+	MaxSitemaps int
+	Timeout     time.Duration
+}
+
+// job api configuration, finished jobs are kept for resultTTL
+// a job still running after timeout is stopped and marked as failed
+// with cancelUnfollowed a running job is cancelled once nobody followed it for unfollowedGrace
+type JobsConfig struct {
+	ResultTTL        time.Duration
+	Timeout          time.Duration
+	CancelUnfollowed bool
+	UnfollowedGrace  time.Duration
+}
+
+// server sent event streams, a heartbeat comment is sent every heartbeatInterval to keep idle connections open
+// a disconnected client can resume an analysis with Last-Event-ID within resumeGrace,
+// the events of a finished analysis can be replayed for replayTTL
+type SSEConfig struct {
+	HeartbeatInterval time.Duration
+	ResumeGrace       time.Duration
+	ReplayTTL         time.Duration
+}
+
+// batch analysis limits, concurrency bounds the analyses running at the same time across all the batches
+type BatchConfig struct {
+	MaxUrls     int
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// overall deadline of a single page analysis, zero means no deadline
+type AnalyzeConfig struct {
+	Timeout time.Duration
 }
@@ -3,7 +3,6 @@ package utils
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -17,7 +16,7 @@ func ContainsIgnoreCase(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
-func JsonToText(output models.Output) (*string, error) {
+func JsonToText(output interface{}) (*string, error) {
 	jsonBytes, err := json.Marshal(output)
 	if err != nil {
 		return nil, errors.New("err when marshelling : " + err.Error())
@@ -26,9 +25,11 @@ func JsonToText(output models.Output) (*string, error) {
 	return &str, nil
 }
 
+// error sent as the last event of a stream
+// marshalling can not fail since ErrorOut only has plain fields
 func ErrStreamObj(err models.ErrorOut) *string {
-	errString := fmt.Sprintf(`{"error" : "%s", "status_code" : "%d"}`, err.Error, err.StatusCode)
-	return &errString
+	errString, _ := JsonToText(err)
+	return errString
 }
 
 func IsExternalLink(link, baseUrl string) bool {
@@ -43,17 +44,87 @@ func IsExternalLink(link, baseUrl string) bool {
 	if u.Host == "" {
 		return false
 	}
+	// a page without a host (a local file) has no internal links with a host
+	if bu.Host == "" {
+		return true
+	}
 	return !strings.Contains(u.Host, bu.Host)
 }
 
+// resolves a link without a host against the page url, relative paths are resolved from the page directory
 func AddInternalHost(link, baseUrl string) string {
-	u, _ := url.Parse(link)
-	bu, _ := url.Parse(baseUrl)
+	u, err := url.Parse(link)
+	if err != nil || u.Host != "" {
+		return link
+	}
+	bu, err := url.Parse(baseUrl)
+	if err != nil {
+		return link
+	}
+	return bu.ResolveReference(u).String()
+}
+
+// resolves a link found in a page against the page url
+// returns an empty string for links that can not be fetched (mailto:, javascript:, invalid urls)
+func ResolveUrl(link, baseUrl string) string {
+	bu, err := url.Parse(baseUrl)
+	if err != nil {
+		return ""
+	}
+	u, err := bu.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
 
+// tells if the link points to the host of the base url or one of its subdomains
+// hosts are compared label by label, so notexample.com is not on example.com
+// a link without a host is on the site of the base url
+func IsSameSite(link, baseUrl string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
 	if u.Host == "" {
-		return bu.Scheme + "://" + bu.Host + link
+		return true
+	}
+	bu, err := url.Parse(baseUrl)
+	if err != nil || bu.Host == "" {
+		return false
+	}
+	host, base := strings.ToLower(u.Hostname()), strings.ToLower(bu.Hostname())
+	return host == base || strings.HasSuffix(host, "."+base)
+}
+
+// tells if the link can be checked by the fetcher once resolved against the page url
+// http(s) links always can, file links only on a local page, other schemes (mailto:, tel:, javascript:) never
+func IsCheckableLink(link, baseUrl string) bool {
+	u, err := url.Parse(AddInternalHost(strings.TrimSpace(link), baseUrl))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return true
+	case "file":
+		return strings.HasPrefix(strings.ToLower(baseUrl), "file:")
+	}
+	return false
+}
+
+// normalizes a url so the same page is not visited twice
+// drops the fragment, lower cases the host and removes a trailing slash
+func NormalizeUrl(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
 	}
-	return link
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
 }
 
 func UrlValidationCheck(input *string) *models.ErrorOut {
@@ -61,7 +132,7 @@ func UrlValidationCheck(input *string) *models.ErrorOut {
 	if len(strings.Split( *input, "://")) == 1 {
 		 *input = "https://" +  *input
 	}
-	errOut := models.ErrorOut{StatusCode: http.StatusBadRequest, Error: "invalid url"}
+	errOut := models.ErrorOut{StatusCode: http.StatusBadRequest, Code: models.ErrCodeInvalidUrl, Error: "invalid url"}
 	parsedVal, err := url.ParseRequestURI( *input)
 	if err != nil {
 		return &errOut
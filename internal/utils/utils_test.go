@@ -2,7 +2,6 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
 	"testing"
 
 	"github.com/RidmaTP/web-analyzer/internal/models"
@@ -70,8 +69,13 @@ func TestErrStreamObj(t *testing.T) {
 	}{
 		{
 			name:      "valid output",
-			input:     models.ErrorOut{StatusCode: 400 , Error: "bad request"},
-			expectOut: fmt.Sprintf(`{"error" : "%s", "status_code" : "%d"}`, "bad request", 400),
+			input:     models.ErrorOut{StatusCode: 400, Code: models.ErrCodeBadRequest, Error: "bad request"},
+			expectOut: `{"status_code":400,"code":"BAD_REQUEST","error":"bad request","retryable":false}`,
+		},
+		{
+			name:      "quotes are escaped",
+			input:     models.ErrorOut{StatusCode: 502, Code: models.ErrCodeFetchFailed, Error: `unexpected "EOF"`, Retryable: true},
+			expectOut: `{"status_code":502,"code":"FETCH_FAILED","error":"unexpected \"EOF\"","retryable":true}`,
 		},
 	}
 
@@ -107,6 +111,12 @@ func TestIsExternalLink(t *testing.T) {
 			link:    "https://www.home24.de/",
 			expect:  true,
 		},
+		{
+			name:    "Remote link of a local file",
+			baseurl: "file:///index.html",
+			link:    "https://lucytech.se/",
+			expect:  true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -116,6 +126,53 @@ func TestIsExternalLink(t *testing.T) {
 		})
 	}
 }
+func TestIsSameSite(t *testing.T) {
+	testCases := []struct {
+		name    string
+		baseurl string
+		link    string
+		expect  bool
+	}{
+		{"Same host", "https://example.com/", "https://example.com/about", true},
+		{"Host case and port", "https://example.com/", "https://EXAMPLE.com:443/about", true},
+		{"Subdomain", "https://example.com/", "https://blog.example.com/", true},
+		{"Look-alike host", "https://example.com/", "https://notexample.com/x", false},
+		{"Host containing the base host", "https://example.com/", "https://example.com.evil.net/", false},
+		{"Parent domain", "https://blog.example.com/", "https://example.com/", false},
+		{"Without host", "https://example.com/", "/about", true},
+		{"Local file", "file:///index.html", "https://example.com/", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, IsSameSite(tc.link, tc.baseurl))
+		})
+	}
+}
+
+func TestIsCheckableLink(t *testing.T) {
+	testCases := []struct {
+		name    string
+		baseurl string
+		link    string
+		expect  bool
+	}{
+		{"Relative", "https://lucytech.se/", "/contact", true},
+		{"Other host", "https://lucytech.se/", "http://www.home24.de/", true},
+		{"Mail", "https://lucytech.se/", "mailto:info@lucytech.se", false},
+		{"Phone", "https://lucytech.se/", "tel:+46123", false},
+		{"Script", "https://lucytech.se/", "javascript:void(0)", false},
+		{"Local file", "file:///index.html", "about.html", true},
+		{"File link of a remote page", "https://lucytech.se/", "file:///etc/passwd", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, IsCheckableLink(tc.link, tc.baseurl))
+		})
+	}
+}
+
 func TestAddInternalHost(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -141,6 +198,18 @@ func TestAddInternalHost(t *testing.T) {
 			link:    "https://www.home24.de",
 			expect:  "https://www.home24.de",
 		},
+		{
+			name:    "Relative to the page directory",
+			baseurl: "https://lucytech.se/docs/index.html",
+			link:    "guide.html",
+			expect:  "https://lucytech.se/docs/guide.html",
+		},
+		{
+			name:    "Local file",
+			baseurl: "file:///docs/index.html",
+			link:    "../about.html",
+			expect:  "file:///about.html",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -170,17 +239,17 @@ func TestValidateUrl(t *testing.T) {
 		{
 			name:    "missing scheme",
 			baseurl: "htt://lucytech.se/",
-			expect:  &models.ErrorOut{StatusCode: 400, Error: "url scheme not found"},
+			expect:  &models.ErrorOut{StatusCode: 400, Code: models.ErrCodeInvalidUrl, Error: "url scheme not found"},
 		},
 		{
 			name:    "missing domain without www.",
 			baseurl: "http://hello",
-			expect:  &models.ErrorOut{StatusCode: 400, Error: "url domain not found"},
+			expect:  &models.ErrorOut{StatusCode: 400, Code: models.ErrCodeInvalidUrl, Error: "url domain not found"},
 		},
 		{
 			name:    "missing domain with www.",
 			baseurl: "http://www.hello",
-			expect:  &models.ErrorOut{StatusCode: 400, Error: "url domain not found"},
+			expect:  &models.ErrorOut{StatusCode: 400, Code: models.ErrCodeInvalidUrl, Error: "url domain not found"},
 		},
 		{
 			name:    "missing scheme completely",